@@ -3,21 +3,36 @@ package gorm
 import (
 	"fmt"
 	"strings"
+
+	"github.com/jinzhu/gorm/clause"
 )
 
 // Define callbacks for creating
 func init() {
 	DefaultCallback.Create().Register("gorm:begin_transaction", beginTransactionCallback)
-	DefaultCallback.Create().Register("gorm:before_create", beforeCreateCallback)
-	DefaultCallback.Create().Register("gorm:save_before_associations", saveBeforeAssociationsCallback)
-	DefaultCallback.Create().Register("gorm:update_time_stamp", updateTimeStampForCreateCallback)
+	DefaultCallback.Create().Register("gorm:before_create", forEachElemScope(beforeCreateCallback))
+	DefaultCallback.Create().Register("gorm:save_before_associations", forEachElemScope(saveBeforeAssociationsCallback))
+	DefaultCallback.Create().Register("gorm:update_time_stamp", forEachElemScope(updateTimeStampForCreateCallback))
 	DefaultCallback.Create().Register("gorm:create", createCallback)
-	DefaultCallback.Create().Register("gorm:force_reload_after_create", forceReloadAfterCreateCallback)
-	DefaultCallback.Create().Register("gorm:save_after_associations", saveAfterAssociationsCallback)
-	DefaultCallback.Create().Register("gorm:after_create", afterCreateCallback)
+	DefaultCallback.Create().Register("gorm:force_reload_after_create", forEachElemScope(forceReloadAfterCreateCallback))
+	DefaultCallback.Create().Register("gorm:save_after_associations", forEachElemScope(saveAfterAssociationsCallback))
+	DefaultCallback.Create().Register("gorm:after_create", forEachElemScope(afterCreateCallback))
 	DefaultCallback.Create().Register("gorm:commit_or_rollback_transaction", commitOrRollbackTransactionCallback)
 }
 
+// forEachElemScope run the callback for each element when creating a slice
+func forEachElemScope(callback func(scope *Scope)) func(scope *Scope) {
+	return func(scope *Scope) {
+		if elemScopes, ok := scope.elemScopes(); ok {
+			for _, elemScope := range elemScopes {
+				callback(elemScope)
+			}
+			return
+		}
+		callback(scope)
+	}
+}
+
 // beforeCreateCallback will invoke `BeforeSave`, `BeforeCreate` method before creating
 func beforeCreateCallback(scope *Scope) {
 	if !scope.HasError() {
@@ -37,94 +52,205 @@ func updateTimeStampForCreateCallback(scope *Scope) {
 	}
 }
 
-// createCallback the callback used to insert data into database
+// createCallback the callback used to insert data into database, a slice will be inserted with multi-row insert statements,
+// split into batches by the `gorm:batch_size` setting and the dialect's limit of bind vars,
+// associations of the elements are not batched, they are still saved element by element before and after inserting
+//     db.Set("gorm:batch_size", 1000).Create(&users)
 func createCallback(scope *Scope) {
 	if !scope.HasError() {
-		defer scope.trace(NowFunc())
+		elemScopes, isSlice := scope.elemScopes()
+		if !isSlice {
+			elemScopes = []*Scope{scope}
+		}
 
 		var (
-			columns, placeholders        []string
-			blankColumnsWithDefaultValue []string
+			batchSize    int
+			maxBindVars  = scope.Dialect().MaxBindVars()
+			batch        []*Scope
+			batchColumns []string
+			rows         [][]interface{}
+			varsCount    int
 		)
 
-		for _, field := range scope.Fields() {
-			if scope.changeableField(field) {
-				if field.IsNormal {
-					if field.IsBlank && field.HasDefaultValue {
-						blankColumnsWithDefaultValue = append(blankColumnsWithDefaultValue, scope.Quote(field.DBName))
-						scope.InstanceSet("gorm:blank_columns_with_default_value", blankColumnsWithDefaultValue)
-					} else if !field.IsPrimaryKey || !field.IsBlank {
-						columns = append(columns, scope.Quote(field.DBName))
-						placeholders = append(placeholders, scope.AddToVars(field.Field.Interface()))
-					}
-				} else if field.Relationship != nil && field.Relationship.Kind == "belongs_to" {
-					for _, foreignKey := range field.Relationship.ForeignDBNames {
-						if foreignField, ok := scope.FieldByName(foreignKey); ok && !scope.changeableField(foreignField) {
-							columns = append(columns, scope.Quote(foreignField.DBName))
-							placeholders = append(placeholders, scope.AddToVars(foreignField.Field.Interface()))
-						}
+		if size, ok := scope.Get("gorm:batch_size"); ok {
+			batchSize, _ = size.(int)
+		}
+
+		for _, elemScope := range elemScopes {
+			columns, values := createColumnsAndValues(elemScope)
+
+			// records are inserted in one statement only if they have the same columns, records without columns use `DEFAULT VALUES`
+			if len(batch) > 0 && (len(columns) == 0 || len(batchColumns) == 0 ||
+				strings.Join(columns, ",") != strings.Join(batchColumns, ",") || (batchSize > 0 && len(batch) >= batchSize)) {
+				insertBatch(scope, batch, batchColumns, rows)
+				batch, rows, varsCount = nil, nil, 0
+			}
+
+			if scope.HasError() {
+				return
+			}
+
+			if maxBindVars > 0 && varsCount+len(values) > maxBindVars && len(batch) > 0 {
+				insertBatch(scope, batch, batchColumns, rows)
+				batch, rows, varsCount = nil, nil, 0
+			}
+
+			batch, batchColumns, rows, varsCount = append(batch, elemScope), columns, append(rows, values), varsCount+len(values)
+		}
+
+		if len(batch) > 0 && !scope.HasError() {
+			insertBatch(scope, batch, batchColumns, rows)
+		}
+	}
+}
+
+// createColumnsAndValues return columns and values that need to be inserted for the record of given scope
+func createColumnsAndValues(scope *Scope) (columns []string, values []interface{}) {
+	var blankColumnsWithDefaultValue []string
+
+	for _, field := range scope.Fields() {
+		if scope.changeableField(field) {
+			if field.IsNormal {
+				// initialize optimistic lock version
+				if _, ok := field.TagSettings["VERSION"]; ok && field.IsBlank {
+					scope.Err(field.Set(1))
+				}
+
+				if field.IsBlank && field.HasDefaultValue {
+					blankColumnsWithDefaultValue = append(blankColumnsWithDefaultValue, scope.Quote(field.DBName))
+					scope.InstanceSet("gorm:blank_columns_with_default_value", blankColumnsWithDefaultValue)
+				} else if !field.IsPrimaryKey || !field.IsBlank {
+					columns = append(columns, field.DBName)
+					values = append(values, field.Field.Interface())
+				}
+			} else if field.Relationship != nil && field.Relationship.Kind == "belongs_to" {
+				for _, foreignKey := range field.Relationship.ForeignDBNames {
+					if foreignField, ok := scope.FieldByName(foreignKey); ok && !scope.changeableField(foreignField) {
+						columns = append(columns, foreignField.DBName)
+						values = append(values, foreignField.Field.Interface())
 					}
 				}
 			}
 		}
+	}
+	return
+}
 
-		var (
-			returningColumn = "*"
-			quotedTableName = scope.QuotedTableName()
-			primaryField    = scope.PrimaryField()
-			extraOption     string
-		)
+// addRowToVars add values to scope's sql vars, return placeholders of the row, e.g. `($1,$2)`
+func addRowToVars(scope *Scope, values []interface{}) string {
+	var placeholders []string
+	for _, value := range values {
+		placeholders = append(placeholders, scope.AddToVars(value))
+	}
+	return fmt.Sprintf("(%v)", strings.Join(placeholders, ","))
+}
+
+// insertBatch insert rows of records of the batch with one statement, and set their primary keys
+func insertBatch(scope *Scope, batch []*Scope, columns []string, rows [][]interface{}) {
+	defer scope.trace(NowFunc())
 
-		if str, ok := scope.Get("gorm:insert_option"); ok {
-			extraOption = fmt.Sprint(str)
+	var (
+		returningColumn = "*"
+		quotedTableName = scope.QuotedTableName()
+		primaryField    = batch[0].PrimaryField()
+		extraOption     string
+	)
+
+	scope.SQLVars = nil
+	if str, ok := scope.Get("gorm:insert_option"); ok {
+		extraOption = fmt.Sprint(str)
+	}
+
+	if primaryField != nil {
+		returningColumn = scope.Quote(primaryField.DBName)
+	}
+
+	// upsert records, conflicted records might be updated or skipped, so their primary keys are queried by conflict columns
+	if onConflict, ok := scope.onConflict(); ok && len(columns) > 0 {
+		var err error
+		if onConflict, err = onConflict.resolve(batch[0], columns); scope.Err(err) != nil {
+			return
 		}
 
-		if primaryField != nil {
-			returningColumn = scope.Quote(primaryField.DBName)
+		var quotedColumns, placeholders []string
+		for _, column := range columns {
+			quotedColumns = append(quotedColumns, scope.Quote(column))
+		}
+		for _, row := range rows {
+			placeholders = append(placeholders, addRowToVars(scope, row))
 		}
 
-		lastInsertIDReturningSuffix := scope.Dialect().LastInsertIDReturningSuffix(quotedTableName, returningColumn)
+		var where string
+		if onConflict.Where != nil {
+			where = scope.AddToVars(onConflict.Where)
+		}
 
-		if len(columns) == 0 {
-			scope.Raw(fmt.Sprintf(
-				"INSERT INTO %v DEFAULT VALUES%v%v",
-				quotedTableName,
-				addExtraSpaceIfExist(extraOption),
-				addExtraSpaceIfExist(lastInsertIDReturningSuffix),
-			))
-		} else {
-			scope.Raw(fmt.Sprintf(
-				"INSERT INTO %v (%v) VALUES (%v)%v%v",
-				scope.QuotedTableName(),
-				strings.Join(columns, ","),
-				strings.Join(placeholders, ","),
-				addExtraSpaceIfExist(extraOption),
-				addExtraSpaceIfExist(lastInsertIDReturningSuffix),
-			))
+		upsertSQL, err := scope.Dialect().UpsertSQL(quotedTableName, quotedColumns, strings.Join(placeholders, ","), onConflict, where)
+		if scope.Err(err) != nil || scope.Raw(upsertSQL).isDryRun() {
+			return
 		}
 
-		// execute create sql
-		if lastInsertIDReturningSuffix == "" || primaryField == nil {
-			if result, err := scope.SQLDB().Exec(scope.SQL, scope.SQLVars...); scope.Err(err) == nil {
-				// set rows affected count
-				scope.db.RowsAffected, _ = result.RowsAffected()
+		if result, err := scope.sqlExec(scope.SQL, scope.SQLVars...); scope.Err(err) == nil {
+			rowsAffected, _ := result.RowsAffected()
+			scope.db.RowsAffected += rowsAffected
+			queryPrimaryKeysByConflictColumns(scope, batch, onConflict.Columns)
+		}
+		return
+	}
 
-				// set primary value to primary field
-				if primaryField != nil && primaryField.IsBlank {
-					if primaryValue, err := result.LastInsertId(); scope.Err(err) == nil {
-						scope.Err(primaryField.Set(primaryValue))
+	lastInsertIDReturningSuffix := scope.Dialect().LastInsertIDReturningSuffix(quotedTableName, returningColumn)
+
+	// the returning clause is populated if the dialect needs `RETURNING` to get primary keys, it is written after the insert option
+	clauses := scope.insertClauses(columns, rows)
+	if lastInsertIDReturningSuffix != "" && primaryField != nil {
+		clauses = append(clauses, clause.Returning{Columns: []clause.Column{{Name: primaryField.DBName}}})
+	}
+
+	scope.Raw(scope.buildClauses(clauses, insertClauseNames...) +
+		addExtraSpaceIfExist(extraOption) +
+		addExtraSpaceIfExist(scope.buildClauses(clauses, "RETURNING")))
+
+	if scope.isDryRun() {
+		return
+	}
+
+	// execute create sql
+	if lastInsertIDReturningSuffix == "" || primaryField == nil {
+		if result, err := scope.sqlExec(scope.SQL, scope.SQLVars...); scope.Err(err) == nil {
+			// set rows affected count
+			rowsAffected, _ := result.RowsAffected()
+			scope.db.RowsAffected += rowsAffected
+
+			// set primary value to primary field, auto increment ids of a batch are consecutive
+			if primaryField != nil && primaryField.IsBlank {
+				if lastInsertID, err := result.LastInsertId(); scope.Err(err) == nil {
+					firstInsertID := scope.Dialect().BatchFirstInsertID(lastInsertID, len(batch))
+					for idx, elemScope := range batch {
+						scope.Err(elemScope.PrimaryField().Set(firstInsertID + int64(idx)))
 					}
 				}
 			}
-		} else {
-			if primaryField.Field.CanAddr() {
-				if err := scope.SQLDB().QueryRow(scope.SQL, scope.SQLVars...).Scan(primaryField.Field.Addr().Interface()); scope.Err(err) == nil {
+		}
+	} else {
+		for _, elemScope := range batch {
+			if !elemScope.PrimaryField().Field.CanAddr() {
+				scope.Err(ErrUnaddressable)
+				return
+			}
+		}
+
+		if rows, err := scope.sqlQuery(scope.SQL, scope.SQLVars...); scope.Err(err) == nil {
+			defer rows.Close()
+
+			// returned rows are in the same order as inserted values
+			for idx := 0; idx < len(batch) && rows.Next(); idx++ {
+				primaryField := batch[idx].PrimaryField()
+				if err := rows.Scan(primaryField.Field.Addr().Interface()); scope.Err(err) == nil {
 					primaryField.IsBlank = false
-					scope.db.RowsAffected = 1
+					scope.db.RowsAffected++
 				}
-			} else {
-				scope.Err(ErrUnaddressable)
 			}
+			scope.Err(rows.Err())
 		}
 	}
 }
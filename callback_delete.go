@@ -3,6 +3,8 @@ package gorm
 import (
 	"errors"
 	"fmt"
+
+	"github.com/jinzhu/gorm/clause"
 )
 
 // Define callbacks for deleting
@@ -36,21 +38,10 @@ func deleteCallback(scope *Scope) {
 		deletedAtField, hasDeletedAtField := scope.FieldByName("DeletedAt")
 
 		if !scope.Search.Unscoped && hasDeletedAtField {
-			scope.Raw(fmt.Sprintf(
-				"UPDATE %v SET %v=%v%v%v",
-				scope.QuotedTableName(),
-				scope.Quote(deletedAtField.DBName),
-				scope.AddToVars(NowFunc()),
-				addExtraSpaceIfExist(scope.CombinedConditionSql()),
-				addExtraSpaceIfExist(extraOption),
-			)).Exec()
+			set := clause.Set{{Column: clause.Column{Name: deletedAtField.DBName}, Value: NowFunc()}}
+			scope.Raw(scope.buildClauses(scope.updateClauses(set), updateClauseNames...) + addExtraSpaceIfExist(extraOption)).Exec()
 		} else {
-			scope.Raw(fmt.Sprintf(
-				"DELETE FROM %v%v%v",
-				scope.QuotedTableName(),
-				addExtraSpaceIfExist(scope.CombinedConditionSql()),
-				addExtraSpaceIfExist(extraOption),
-			)).Exec()
+			scope.Raw(scope.buildClauses(scope.deleteClauses(), deleteClauseNames...) + addExtraSpaceIfExist(extraOption)).Exec()
 		}
 	}
 }
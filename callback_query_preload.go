@@ -274,7 +274,7 @@ func (scope *Scope) handleManyToManyPreload(field *Field, conditions []interface
 
 	rows, err := preloadDB.Rows()
 
-	if scope.Err(err) != nil {
+	if scope.Err(err) != nil || rows == nil {
 		return
 	}
 	defer rows.Close()
@@ -3,7 +3,8 @@ package gorm
 import (
 	"errors"
 	"fmt"
-	"strings"
+
+	"github.com/jinzhu/gorm/clause"
 )
 
 // Define callbacks for updating
@@ -56,22 +57,24 @@ func updateTimeStampForUpdateCallback(scope *Scope) {
 // updateCallback the callback used to update data to database
 func updateCallback(scope *Scope) {
 	if !scope.HasError() {
-		var sqls []string
+		var (
+			set          clause.Set
+			versionField = optimisticLockField(scope)
+		)
 
 		if updateAttrs, ok := scope.InstanceGet("gorm:update_attrs"); ok {
 			for column, value := range updateAttrs.(map[string]interface{}) {
-				sqls = append(sqls, fmt.Sprintf("%v = %v", scope.Quote(column), scope.AddToVars(value)))
+				set = append(set, clause.Assignment{Column: clause.Column{Name: column}, Value: value})
 			}
 		} else {
 			for _, field := range scope.Fields() {
-				if scope.changeableField(field) {
+				if scope.changeableField(field) && field != versionField {
 					if !field.IsPrimaryKey && field.IsNormal {
-						sqls = append(sqls, fmt.Sprintf("%v = %v", scope.Quote(field.DBName), scope.AddToVars(field.Field.Interface())))
+						set = append(set, clause.Assignment{Column: clause.Column{Name: field.DBName}, Value: field.Field.Interface()})
 					} else if relationship := field.Relationship; relationship != nil && relationship.Kind == "belongs_to" {
 						for _, foreignKey := range relationship.ForeignDBNames {
 							if foreignField, ok := scope.FieldByName(foreignKey); ok && !scope.changeableField(foreignField) {
-								sqls = append(sqls,
-									fmt.Sprintf("%v = %v", scope.Quote(foreignField.DBName), scope.AddToVars(foreignField.Field.Interface())))
+								set = append(set, clause.Assignment{Column: clause.Column{Name: foreignField.DBName}, Value: foreignField.Field.Interface()})
 							}
 						}
 					}
@@ -84,16 +87,49 @@ func updateCallback(scope *Scope) {
 			extraOption = fmt.Sprint(str)
 		}
 
-		if len(sqls) > 0 {
-			scope.Raw(fmt.Sprintf(
-				"UPDATE %v SET %v%v%v",
-				scope.QuotedTableName(),
-				strings.Join(sqls, ", "),
-				addExtraSpaceIfExist(scope.CombinedConditionSql()),
-				addExtraSpaceIfExist(extraOption),
-			)).Exec()
+		if len(set) > 0 {
+			var version int64
+			if versionField != nil {
+				// only update the record if its version hasn't been changed since loaded
+				version = toInt64(versionField.Field)
+				quotedVersionColumn := scope.Quote(versionField.DBName)
+				set = append(set, clause.Assignment{Column: clause.Column{Name: versionField.DBName}, Value: clause.Expr{SQL: quotedVersionColumn + " + 1"}})
+				scope.Search.Where(fmt.Sprintf("%v.%v = ?", scope.QuotedTableName(), quotedVersionColumn), version)
+			}
+
+			scope.Raw(scope.buildClauses(scope.updateClauses(set), updateClauseNames...) + addExtraSpaceIfExist(extraOption)).Exec()
+
+			if versionField != nil && !scope.HasError() && !scope.isDryRun() {
+				if scope.db.RowsAffected == 0 {
+					scope.Err(ErrStaleObject)
+				} else {
+					scope.Err(versionField.Set(version + 1))
+				}
+			}
+		}
+	}
+}
+
+// optimisticLockField return the version field used to lock current record when updating, returns nil if current value isn't a loaded record with version,
+// the version is set explicitly, or optimistic locking is disabled with `gorm:optimistic_lock`
+func optimisticLockField(scope *Scope) *Field {
+	if optimisticLock, ok := scope.Get("gorm:optimistic_lock"); ok {
+		if enable, ok := optimisticLock.(bool); ok && !enable {
+			return nil
+		}
+	}
+
+	for _, field := range scope.Fields() {
+		if _, ok := field.TagSettings["VERSION"]; ok && !field.IsBlank {
+			if updateAttrs, ok := scope.InstanceGet("gorm:update_attrs"); ok {
+				if _, ok := updateAttrs.(map[string]interface{})[field.DBName]; ok {
+					return nil
+				}
+			}
+			return field
 		}
 	}
+	return nil
 }
 
 // afterUpdateCallback will invoke `AfterUpdate`, `AfterSave` method after updating
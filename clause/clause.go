@@ -0,0 +1,160 @@
+// Package clause defines clauses of sql statements, gorm's callbacks populate clauses of statements and render them through the dialect,
+// clauses added with `DB.Clauses` are merged into them before rendering, e.g:
+//     db.Clauses(clause.Where{Exprs: []clause.Expression{clause.Eq{Column: "name", Value: "jinzhu"}}}).Find(&users)
+//     // SELECT * FROM "users" WHERE "name" = $1
+package clause
+
+import "fmt"
+
+// Builder write sql of clauses, it quotes names and binds vars with the dialect of the statement
+type Builder interface {
+	// WriteString write sql as is
+	WriteString(sql string)
+	// WriteQuoted write quoted name of a table or column, `CurrentTable` is written as the statement's table, `PrimaryKey` as its primary key
+	WriteQuoted(name string)
+	// AddVar add values to vars and write their placeholders separated by commas, slices are expanded to their elements, expressions are built
+	AddVar(values ...interface{})
+}
+
+// Expression a part of sql which could be built with vars
+type Expression interface {
+	Build(builder Builder)
+}
+
+// Interface a clause of statements, a clause replaces the one with the same name unless it implements `Merger`
+type Interface interface {
+	// Name name of the clause, e.g. `WHERE`, which decides its position in statements
+	Name() string
+	Expression
+}
+
+// Merger clauses merged into the previous clause with the same name, e.g. conditions of `Where` clauses are combined with AND
+type Merger interface {
+	MergeClause(previous Interface) Interface
+}
+
+// CurrentTable refers to the table of the statement, which is the model's table or the one set with `Table`
+const CurrentTable = "@@table"
+
+// PrimaryKey refers to the primary key of the statement's table, e.g. conditions of `db.Where(10)` are `Eq{Column: Column{Table: CurrentTable, Name: PrimaryKey}, Value: 10}`
+const PrimaryKey = "@@primary_key"
+
+// Column a column written quoted with its alias, `Raw` columns are written as is, e.g. `count(*)`
+type Column struct {
+	Table string
+	Name  string
+	Alias string
+	Raw   bool
+}
+
+// Build write the column
+func (column Column) Build(builder Builder) {
+	if column.Raw {
+		builder.WriteString(column.Name)
+	} else {
+		if column.Table != "" {
+			builder.WriteQuoted(column.Table)
+			builder.WriteString(".")
+		}
+		builder.WriteQuoted(column.Name)
+	}
+
+	if column.Alias != "" {
+		builder.WriteString(" AS ")
+		builder.WriteQuoted(column.Alias)
+	}
+}
+
+// Table a table written quoted with its alias, `Raw` tables are written as is
+type Table struct {
+	Name  string
+	Alias string
+	Raw   bool
+}
+
+// Build write the table
+func (table Table) Build(builder Builder) {
+	if table.Raw {
+		builder.WriteString(table.Name)
+	} else {
+		builder.WriteQuoted(table.Name)
+	}
+
+	if table.Alias != "" {
+		builder.WriteString(" ")
+		builder.WriteQuoted(table.Alias)
+	}
+}
+
+// buildColumn write the column, which is a column name or an expression, e.g. `Column`
+func buildColumn(builder Builder, column interface{}) {
+	if expr, ok := column.(Expression); ok {
+		expr.Build(builder)
+	} else {
+		builder.WriteQuoted(fmt.Sprint(column))
+	}
+}
+
+// buildColumns write columns separated by commas
+func buildColumns(builder Builder, columns []Column) {
+	for idx, column := range columns {
+		if idx > 0 {
+			builder.WriteString(",")
+		}
+		column.Build(builder)
+	}
+}
+
+// lazyBuilder write its prefix before the first sql written by an expression, so nothing is written for expressions building nothing
+type lazyBuilder struct {
+	Builder
+	prefix  string
+	written bool
+}
+
+func (builder *lazyBuilder) writePrefix() {
+	if !builder.written {
+		builder.written = true
+		builder.Builder.WriteString(builder.prefix)
+	}
+}
+
+func (builder *lazyBuilder) WriteString(sql string) {
+	if sql != "" {
+		builder.writePrefix()
+		builder.Builder.WriteString(sql)
+	}
+}
+
+func (builder *lazyBuilder) WriteQuoted(name string) {
+	builder.writePrefix()
+	builder.Builder.WriteQuoted(name)
+}
+
+func (builder *lazyBuilder) AddVar(values ...interface{}) {
+	builder.writePrefix()
+	builder.Builder.AddVar(values...)
+}
+
+// buildConditions write expressions joined with the separator after the prefix, empty expressions are skipped and nothing is written if all of them are empty,
+// expressions are wrapped in parentheses if there are more than one, return whether anything has been written
+func buildConditions(builder Builder, prefix, separator string, exprs []Expression) (written bool) {
+	for _, expr := range exprs {
+		lazy := &lazyBuilder{Builder: builder, prefix: prefix}
+		if written {
+			lazy.prefix = separator
+		}
+		if len(exprs) > 1 {
+			lazy.prefix += "("
+		}
+
+		expr.Build(lazy)
+		if lazy.written {
+			if len(exprs) > 1 {
+				builder.WriteString(")")
+			}
+			written = true
+		}
+	}
+	return
+}
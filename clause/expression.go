@@ -0,0 +1,170 @@
+package clause
+
+import "strings"
+
+// Expr raw sql with vars, each `?` is replaced by the placeholder of the var in the same position, e.g:
+//     clause.Expr{SQL: "age > ? AND role IN (?)", Vars: []interface{}{18, []string{"admin", "user"}}}
+type Expr struct {
+	SQL  string
+	Vars []interface{}
+}
+
+// Build write the sql with placeholders of its vars, extra question marks are written as is
+func (expr Expr) Build(builder Builder) {
+	sql, idx := expr.SQL, 0
+	for {
+		pos := strings.Index(sql, "?")
+		if pos == -1 || idx >= len(expr.Vars) {
+			break
+		}
+
+		builder.WriteString(sql[:pos])
+		builder.AddVar(expr.Vars[idx])
+		sql, idx = sql[pos+1:], idx+1
+	}
+	builder.WriteString(sql)
+}
+
+// Eq equal condition of the column, a column name or an expression, compared with `IS NULL` if the value is nil
+type Eq struct {
+	Column interface{}
+	Value  interface{}
+}
+
+// Build write the condition
+func (eq Eq) Build(builder Builder) {
+	buildColumn(builder, eq.Column)
+	if eq.Value == nil {
+		builder.WriteString(" IS NULL")
+	} else {
+		builder.WriteString(" = ")
+		builder.AddVar(eq.Value)
+	}
+}
+
+// Neq not equal condition of the column, compared with `IS NOT NULL` if the value is nil
+type Neq Eq
+
+// Build write the condition
+func (neq Neq) Build(builder Builder) {
+	buildColumn(builder, neq.Column)
+	if neq.Value == nil {
+		builder.WriteString(" IS NOT NULL")
+	} else {
+		builder.WriteString(" <> ")
+		builder.AddVar(neq.Value)
+	}
+}
+
+// Gt greater than condition of the column
+type Gt Eq
+
+// Build write the condition
+func (gt Gt) Build(builder Builder) {
+	buildComparison(builder, gt.Column, " > ", gt.Value)
+}
+
+// Gte greater than or equal condition of the column
+type Gte Eq
+
+// Build write the condition
+func (gte Gte) Build(builder Builder) {
+	buildComparison(builder, gte.Column, " >= ", gte.Value)
+}
+
+// Lt less than condition of the column
+type Lt Eq
+
+// Build write the condition
+func (lt Lt) Build(builder Builder) {
+	buildComparison(builder, lt.Column, " < ", lt.Value)
+}
+
+// Lte less than or equal condition of the column
+type Lte Eq
+
+// Build write the condition
+func (lte Lte) Build(builder Builder) {
+	buildComparison(builder, lte.Column, " <= ", lte.Value)
+}
+
+func buildComparison(builder Builder, column interface{}, operator string, value interface{}) {
+	buildColumn(builder, column)
+	builder.WriteString(operator)
+	builder.AddVar(value)
+}
+
+// IN condition that the column is one of values, it is never true if there are no values
+type IN struct {
+	Column interface{}
+	Values []interface{}
+}
+
+// Build write the condition
+func (in IN) Build(builder Builder) {
+	buildColumn(builder, in.Column)
+	if len(in.Values) == 0 {
+		builder.WriteString(" IN (NULL)")
+		return
+	}
+
+	builder.WriteString(" IN (")
+	builder.AddVar(in.Values...)
+	builder.WriteString(")")
+}
+
+// AndConditions conditions combined with AND, built by `And`
+type AndConditions struct {
+	Exprs []Expression
+}
+
+// And combine conditions with AND, e.g. `clause.And(clause.Eq{Column: "name", Value: "jinzhu"}, clause.Gt{Column: "age", Value: 18})`
+func And(exprs ...Expression) Expression {
+	return AndConditions{Exprs: exprs}
+}
+
+// Build write conditions in parentheses if there are more than one
+func (and AndConditions) Build(builder Builder) {
+	buildGroup(builder, " AND ", and.Exprs)
+}
+
+// OrConditions conditions combined with OR, built by `Or`
+type OrConditions struct {
+	Exprs []Expression
+}
+
+// Or combine conditions with OR, e.g. `clause.Or(clause.Eq{Column: "role", Value: "admin"}, clause.Eq{Column: "role", Value: "owner"})`
+func Or(exprs ...Expression) Expression {
+	return OrConditions{Exprs: exprs}
+}
+
+// Build write conditions in parentheses if there are more than one
+func (or OrConditions) Build(builder Builder) {
+	buildGroup(builder, " OR ", or.Exprs)
+}
+
+// NotConditions negated conditions, built by `Not`
+type NotConditions struct {
+	Exprs []Expression
+}
+
+// Not negate conditions combined with AND, e.g. `clause.Not(clause.Eq{Column: "name", Value: "jinzhu"})`
+func Not(exprs ...Expression) Expression {
+	return NotConditions{Exprs: exprs}
+}
+
+// Build write `NOT` before conditions
+func (not NotConditions) Build(builder Builder) {
+	if buildConditions(builder, "NOT (", " AND ", not.Exprs) {
+		builder.WriteString(")")
+	}
+}
+
+// buildGroup write conditions joined with the separator, wrapped in parentheses if there are more than one
+func buildGroup(builder Builder, separator string, exprs []Expression) {
+	if len(exprs) == 1 {
+		exprs[0].Build(builder)
+	} else if buildConditions(builder, "(", separator, exprs) {
+		builder.WriteString(")")
+	}
+}
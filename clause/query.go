@@ -0,0 +1,279 @@
+package clause
+
+import "strconv"
+
+// Select select columns, or the expression if it is set, all columns are selected if both are blank
+type Select struct {
+	Distinct   bool
+	Columns    []Column
+	Expression Expression
+}
+
+// Name return `SELECT`
+func (Select) Name() string {
+	return "SELECT"
+}
+
+// Build write the clause
+func (s Select) Build(builder Builder) {
+	builder.WriteString("SELECT ")
+	if s.Distinct {
+		builder.WriteString("DISTINCT ")
+	}
+
+	if s.Expression != nil {
+		s.Expression.Build(builder)
+	} else if len(s.Columns) > 0 {
+		buildColumns(builder, s.Columns)
+	} else {
+		builder.WriteString("*")
+	}
+}
+
+// From tables to select from with joins, or the expression if it is set, e.g. a subquery
+type From struct {
+	Tables     []Table
+	Expression Expression
+	Joins      []Join
+}
+
+// Name return `FROM`
+func (From) Name() string {
+	return "FROM"
+}
+
+// Build write the clause
+func (from From) Build(builder Builder) {
+	builder.WriteString("FROM ")
+	if from.Expression != nil {
+		from.Expression.Build(builder)
+	} else {
+		for idx, table := range from.Tables {
+			if idx > 0 {
+				builder.WriteString(",")
+			}
+			table.Build(builder)
+		}
+	}
+
+	for _, join := range from.Joins {
+		lazy := &lazyBuilder{Builder: builder, prefix: " "}
+		join.Build(lazy)
+	}
+}
+
+// MergeClause append joins to the previous clause if the clause doesn't have tables, e.g. `db.Clauses(clause.From{Joins: joins})`
+func (from From) MergeClause(previous Interface) Interface {
+	if previousFrom, ok := previous.(From); ok && len(from.Tables) == 0 && from.Expression == nil {
+		previousFrom.Joins = append(append([]Join{}, previousFrom.Joins...), from.Joins...)
+		return previousFrom
+	}
+	return from
+}
+
+// JoinType type of joins
+type JoinType string
+
+// Join types
+const (
+	CrossJoin JoinType = "CROSS"
+	InnerJoin JoinType = "INNER"
+	LeftJoin  JoinType = "LEFT"
+	RightJoin JoinType = "RIGHT"
+)
+
+// Join join the table on conditions combined with AND, or the expression if it is set, e.g. raw sql of a join
+type Join struct {
+	Type       JoinType
+	Table      Table
+	ON         []Expression
+	Expression Expression
+}
+
+// Build write the join
+func (join Join) Build(builder Builder) {
+	if join.Expression != nil {
+		join.Expression.Build(builder)
+		return
+	}
+
+	if join.Type != "" {
+		builder.WriteString(string(join.Type) + " ")
+	}
+	builder.WriteString("JOIN ")
+	join.Table.Build(builder)
+	buildConditions(builder, " ON ", " AND ", join.ON)
+}
+
+// Where conditions combined with AND, empty conditions are skipped, nothing is written if there are no conditions
+type Where struct {
+	Exprs []Expression
+}
+
+// Name return `WHERE`
+func (Where) Name() string {
+	return "WHERE"
+}
+
+// Build write the clause
+func (where Where) Build(builder Builder) {
+	buildConditions(builder, "WHERE ", " AND ", where.Exprs)
+}
+
+// MergeClause combine conditions with the previous clause's conditions with AND
+func (where Where) MergeClause(previous Interface) Interface {
+	if previousWhere, ok := previous.(Where); ok {
+		where.Exprs = append(append([]Expression{}, previousWhere.Exprs...), where.Exprs...)
+	}
+	return where
+}
+
+// GroupBy group by columns, having conditions are combined with AND
+type GroupBy struct {
+	Columns []Column
+	Having  []Expression
+}
+
+// Name return `GROUP BY`
+func (GroupBy) Name() string {
+	return "GROUP BY"
+}
+
+// Build write the clause
+func (groupBy GroupBy) Build(builder Builder) {
+	if len(groupBy.Columns) > 0 {
+		builder.WriteString("GROUP BY ")
+		buildColumns(builder, groupBy.Columns)
+	}
+
+	prefix := "HAVING "
+	if len(groupBy.Columns) > 0 {
+		prefix = " HAVING "
+	}
+	buildConditions(builder, prefix, " AND ", groupBy.Having)
+}
+
+// MergeClause append columns and having conditions to the previous clause's
+func (groupBy GroupBy) MergeClause(previous Interface) Interface {
+	if previousGroupBy, ok := previous.(GroupBy); ok {
+		groupBy.Columns = append(append([]Column{}, previousGroupBy.Columns...), groupBy.Columns...)
+		groupBy.Having = append(append([]Expression{}, previousGroupBy.Having...), groupBy.Having...)
+	}
+	return groupBy
+}
+
+// OrderByColumn a column to order by
+type OrderByColumn struct {
+	Column Column
+	Desc   bool
+}
+
+// OrderBy order by columns, or the expression if it is set
+type OrderBy struct {
+	Columns    []OrderByColumn
+	Expression Expression
+}
+
+// Name return `ORDER BY`
+func (OrderBy) Name() string {
+	return "ORDER BY"
+}
+
+// Build write the clause
+func (orderBy OrderBy) Build(builder Builder) {
+	if orderBy.Expression != nil {
+		lazy := &lazyBuilder{Builder: builder, prefix: "ORDER BY "}
+		orderBy.Expression.Build(lazy)
+		return
+	}
+
+	for idx, column := range orderBy.Columns {
+		if idx == 0 {
+			builder.WriteString("ORDER BY ")
+		} else {
+			builder.WriteString(",")
+		}
+
+		column.Column.Build(builder)
+		if column.Desc {
+			builder.WriteString(" DESC")
+		}
+	}
+}
+
+// MergeClause append columns to the previous clause's, the clause replaces the previous one if either of them is an expression
+func (orderBy OrderBy) MergeClause(previous Interface) Interface {
+	if previousOrderBy, ok := previous.(OrderBy); ok && orderBy.Expression == nil && previousOrderBy.Expression == nil {
+		orderBy.Columns = append(append([]OrderByColumn{}, previousOrderBy.Columns...), orderBy.Columns...)
+	}
+	return orderBy
+}
+
+// Limit limit and offset of queries, they are omitted if they aren't positive
+type Limit struct {
+	Limit  int
+	Offset int
+}
+
+// Name return `LIMIT`
+func (Limit) Name() string {
+	return "LIMIT"
+}
+
+// Build write the clause
+func (limit Limit) Build(builder Builder) {
+	if limit.Limit > 0 {
+		builder.WriteString("LIMIT " + strconv.Itoa(limit.Limit))
+	}
+
+	if limit.Offset > 0 {
+		if limit.Limit > 0 {
+			builder.WriteString(" ")
+		}
+		builder.WriteString("OFFSET " + strconv.Itoa(limit.Offset))
+	}
+}
+
+// MergeClause keep the previous clause's limit or offset if the clause's isn't set
+func (limit Limit) MergeClause(previous Interface) Interface {
+	if previousLimit, ok := previous.(Limit); ok {
+		if limit.Limit == 0 {
+			limit.Limit = previousLimit.Limit
+		}
+		if limit.Offset == 0 {
+			limit.Offset = previousLimit.Offset
+		}
+	}
+	return limit
+}
+
+// Locking strengths and options
+const (
+	LockingStrengthUpdate    = "UPDATE"
+	LockingStrengthShare     = "SHARE"
+	LockingOptionsSkipLocked = "SKIP LOCKED"
+	LockingOptionsNoWait     = "NOWAIT"
+)
+
+// Locking lock selected rows, e.g. `FOR UPDATE SKIP LOCKED`
+type Locking struct {
+	Strength string
+	Options  string
+}
+
+// Name return `FOR`
+func (Locking) Name() string {
+	return "FOR"
+}
+
+// Build write the clause, nothing is written if the strength is blank
+func (locking Locking) Build(builder Builder) {
+	if locking.Strength == "" {
+		return
+	}
+
+	builder.WriteString("FOR " + locking.Strength)
+	if locking.Options != "" {
+		builder.WriteString(" " + locking.Options)
+	}
+}
@@ -0,0 +1,170 @@
+package clause
+
+// Insert insert into the table, modifier is written after `INSERT`, e.g. `IGNORE`
+type Insert struct {
+	Table    Table
+	Modifier string
+}
+
+// Name return `INSERT`
+func (Insert) Name() string {
+	return "INSERT"
+}
+
+// Build write the clause
+func (insert Insert) Build(builder Builder) {
+	builder.WriteString("INSERT ")
+	if insert.Modifier != "" {
+		builder.WriteString(insert.Modifier + " ")
+	}
+	builder.WriteString("INTO ")
+	insert.Table.Build(builder)
+}
+
+// Values rows of values of columns to insert, default values are inserted if there are no columns
+type Values struct {
+	Columns []Column
+	Values  [][]interface{}
+}
+
+// Name return `VALUES`
+func (Values) Name() string {
+	return "VALUES"
+}
+
+// Build write the clause
+func (values Values) Build(builder Builder) {
+	if len(values.Columns) == 0 {
+		builder.WriteString("DEFAULT VALUES")
+		return
+	}
+
+	builder.WriteString("(")
+	buildColumns(builder, values.Columns)
+	builder.WriteString(") VALUES ")
+	for idx, row := range values.Values {
+		if idx > 0 {
+			builder.WriteString(",")
+		}
+
+		builder.WriteString("(")
+		for i, value := range row {
+			if i > 0 {
+				builder.WriteString(",")
+			}
+			builder.AddVar(value)
+		}
+		builder.WriteString(")")
+	}
+}
+
+// Update update the table, modifier is written after `UPDATE`, e.g. `LOW_PRIORITY`
+type Update struct {
+	Table    Table
+	Modifier string
+}
+
+// Name return `UPDATE`
+func (Update) Name() string {
+	return "UPDATE"
+}
+
+// Build write the clause
+func (update Update) Build(builder Builder) {
+	builder.WriteString("UPDATE ")
+	if update.Modifier != "" {
+		builder.WriteString(update.Modifier + " ")
+	}
+	update.Table.Build(builder)
+}
+
+// Delete delete from the table, modifier is written after `DELETE`, e.g. `LOW_PRIORITY`
+type Delete struct {
+	Table    Table
+	Modifier string
+}
+
+// Name return `DELETE`
+func (Delete) Name() string {
+	return "DELETE"
+}
+
+// Build write the clause
+func (d Delete) Build(builder Builder) {
+	builder.WriteString("DELETE ")
+	if d.Modifier != "" {
+		builder.WriteString(d.Modifier + " ")
+	}
+	builder.WriteString("FROM ")
+	d.Table.Build(builder)
+}
+
+// Assignment set the column to the value, which could be an expression, e.g. `clause.Expr{SQL: "age + 1"}`
+type Assignment struct {
+	Column Column
+	Value  interface{}
+}
+
+// Set assignments of updated columns
+type Set []Assignment
+
+// Name return `SET`
+func (Set) Name() string {
+	return "SET"
+}
+
+// Build write the clause, nothing is written if there are no assignments
+func (set Set) Build(builder Builder) {
+	for idx, assignment := range set {
+		if idx == 0 {
+			builder.WriteString("SET ")
+		} else {
+			builder.WriteString(", ")
+		}
+
+		assignment.Column.Build(builder)
+		builder.WriteString(" = ")
+		builder.AddVar(assignment.Value)
+	}
+}
+
+// MergeClause add assignments to the previous clause's, assignments of the same column are replaced
+func (set Set) MergeClause(previous Interface) Interface {
+	previousSet, ok := previous.(Set)
+	if !ok {
+		return set
+	}
+
+	merged := append(Set{}, previousSet...)
+	for _, assignment := range set {
+		replaced := false
+		for idx, previousAssignment := range merged {
+			if previousAssignment.Column == assignment.Column {
+				merged[idx], replaced = assignment, true
+			}
+		}
+
+		if !replaced {
+			merged = append(merged, assignment)
+		}
+	}
+	return merged
+}
+
+// Returning return values of columns from inserted or updated rows
+type Returning struct {
+	Columns []Column
+}
+
+// Name return `RETURNING`
+func (Returning) Name() string {
+	return "RETURNING"
+}
+
+// Build write the clause, nothing is written if there are no columns
+func (returning Returning) Build(builder Builder) {
+	if len(returning.Columns) > 0 {
+		builder.WriteString("RETURNING ")
+		buildColumns(builder, returning.Columns)
+	}
+}
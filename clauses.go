@@ -0,0 +1,349 @@
+package gorm
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/jinzhu/gorm/clause"
+)
+
+// names of clauses in the order they are rendered in statements
+var (
+	queryClauseNames  = []string{"SELECT", "FROM", "WHERE", "GROUP BY", "ORDER BY", "LIMIT", "FOR"}
+	updateClauseNames = []string{"UPDATE", "SET", "WHERE", "ORDER BY", "LIMIT"}
+	deleteClauseNames = []string{"DELETE", "WHERE", "ORDER BY", "LIMIT"}
+	insertClauseNames = []string{"INSERT", "VALUES"}
+)
+
+// searchConditions conditions of the search, where and not conditions are combined with AND, then or conditions with OR,
+// each condition is written in parentheses, so are conditions of groups, e.g. `(name = $1) AND ((age > $2) OR ("users"."role" = $3))`
+type searchConditions struct {
+	ands []clause.Expression
+	ors  []clause.Expression
+}
+
+func (conditions searchConditions) Build(builder clause.Builder) {
+	prefix := ""
+	if writeConditions(builder, "", " AND ", conditions.ands) {
+		prefix = " OR "
+	}
+	writeConditions(builder, prefix, " OR ", conditions.ors)
+}
+
+// writeConditions write conditions joined with the separator after the prefix, empty conditions are skipped, return whether anything has been written
+func writeConditions(builder clause.Builder, prefix, separator string, exprs []clause.Expression) (written bool) {
+	for _, expr := range exprs {
+		if emptyCondition(expr) {
+			continue
+		}
+
+		if written {
+			builder.WriteString(separator)
+		} else {
+			builder.WriteString(prefix)
+		}
+		writeCondition(builder, expr)
+		written = true
+	}
+	return
+}
+
+// writeCondition write the condition in parentheses, conditions of groups are written in parentheses too
+func writeCondition(builder clause.Builder, expr clause.Expression) {
+	builder.WriteString("(")
+	switch expr := expr.(type) {
+	case clause.AndConditions:
+		writeConditions(builder, "", " AND ", expr.Exprs)
+	case clause.OrConditions:
+		writeConditions(builder, "", " OR ", expr.Exprs)
+	case clause.NotConditions:
+		builder.WriteString("NOT ")
+		if len(expr.Exprs) == 1 {
+			writeCondition(builder, expr.Exprs[0])
+		} else {
+			writeCondition(builder, clause.AndConditions{Exprs: expr.Exprs})
+		}
+	default:
+		expr.Build(builder)
+	}
+	builder.WriteString(")")
+}
+
+// emptyCondition return whether the condition writes nothing, e.g. `Where("")` or groups of empty conditions
+func emptyCondition(expr clause.Expression) bool {
+	var exprs []clause.Expression
+	switch expr := expr.(type) {
+	case nil:
+		return true
+	case clause.Expr:
+		return expr.SQL == ""
+	case clause.AndConditions:
+		exprs = expr.Exprs
+	case clause.OrConditions:
+		exprs = expr.Exprs
+	case clause.NotConditions:
+		exprs = expr.Exprs
+	default:
+		return false
+	}
+
+	for _, expr := range exprs {
+		if !emptyCondition(expr) {
+			return false
+		}
+	}
+	return true
+}
+
+// WriteString write sql of clauses, `Scope` is the builder of clauses rendered by callbacks
+func (scope *Scope) WriteString(sql string) {
+	scope.sqlBuilder.WriteString(sql)
+}
+
+// WriteQuoted write the quoted table or column name, `clause.CurrentTable` is written as the quoted table name,
+// `clause.PrimaryKey` as the quoted primary key
+func (scope *Scope) WriteQuoted(name string) {
+	switch name {
+	case clause.CurrentTable:
+		scope.WriteString(scope.QuotedTableName())
+	case clause.PrimaryKey:
+		scope.WriteString(scope.Quote(scope.PrimaryKey()))
+	default:
+		scope.WriteString(scope.Quote(name))
+	}
+}
+
+// AddVar add values to sql vars and write their placeholders separated by commas, expressions are built,
+// slices except bytes are expanded to their elements, e.g. `IN (?)` with `[]int{1, 2}` is `IN ($1,$2)`
+func (scope *Scope) AddVar(values ...interface{}) {
+	for idx, value := range values {
+		if idx > 0 {
+			scope.WriteString(",")
+		}
+
+		switch value := value.(type) {
+		case clause.Expression:
+			value.Build(scope)
+		case driver.Valuer, *SQLExpr, *DB:
+			scope.WriteString(scope.AddToVars(value))
+		default:
+			if reflectValue := reflect.ValueOf(value); reflectValue.Kind() == reflect.Slice && reflectValue.Type().Elem().Kind() != reflect.Uint8 {
+				if reflectValue.Len() == 0 {
+					scope.WriteString("NULL")
+				}
+				for i := 0; i < reflectValue.Len(); i++ {
+					if i > 0 {
+						scope.WriteString(",")
+					}
+					scope.AddVar(reflectValue.Index(i).Interface())
+				}
+			} else {
+				scope.WriteString(scope.AddToVars(value))
+			}
+		}
+	}
+}
+
+// buildExpr return sql of the expression built out of clauses, e.g. sql of conditions used by raw sql, its vars are added to the scope's vars
+func (scope *Scope) buildExpr(expr clause.Expression) string {
+	length := scope.sqlBuilder.Len()
+	expr.Build(scope)
+	sql := scope.sqlBuilder.String()[length:]
+	scope.sqlBuilder.Truncate(length)
+	return sql
+}
+
+// AddClause add clauses to current operation, they are merged into clauses populated by callbacks like clauses added with `DB.Clauses`,
+// used by callbacks registered before the default ones
+func (scope *Scope) AddClause(clauses ...clause.Interface) {
+	scope.Search.clauses = append(scope.Search.clauses, clauses...)
+}
+
+// buildClauses merge clauses added with `DB.Clauses` into clauses populated by callbacks, and render clauses of names in order through the dialect,
+// the returned sql's placeholders are not converted
+func (scope *Scope) buildClauses(populated []clause.Interface, names ...string) string {
+	clauses := map[string]clause.Interface{}
+	for _, c := range populated {
+		clauses[c.Name()] = c
+	}
+
+	for _, c := range scope.Search.clauses {
+		if merger, ok := c.(clause.Merger); ok {
+			if previous, ok := clauses[c.Name()]; ok {
+				c = merger.MergeClause(previous)
+			}
+		}
+		clauses[c.Name()] = c
+	}
+
+	for _, name := range names {
+		if name == "FOR" {
+			scope.resolveLocking(clauses)
+		}
+	}
+
+	scope.sqlBuilder.Reset()
+	for _, name := range names {
+		if c, ok := clauses[name]; ok {
+			// clauses that write nothing, e.g. `Where` without conditions, are omitted with their separators
+			length := scope.sqlBuilder.Len()
+			if length > 0 {
+				scope.WriteString(" ")
+			}
+
+			if !scope.Dialect().BuildClause(c, scope) {
+				c.Build(scope)
+			}
+
+			if length > 0 && scope.sqlBuilder.Len() == length+1 {
+				scope.sqlBuilder.Truncate(length)
+			}
+		}
+	}
+
+	sql := scope.sqlBuilder.String()
+	scope.sqlBuilder.Reset()
+	return sql
+}
+
+// queryClauses populate clauses of the query from its search
+func (scope *Scope) queryClauses() []clause.Interface {
+	var (
+		search        = scope.Search
+		selectQuery   = clause.Select{}
+		from          = clause.From{Tables: []clause.Table{{Name: clause.CurrentTable}}}
+		joinedColumns []clause.Column
+	)
+
+	if search.tableExpr != nil {
+		from = clause.From{Expression: clause.Expr{SQL: search.tableExpr.expr, Vars: search.tableExpr.args}}
+	}
+
+	for _, condition := range search.joinConditions {
+		if join, columns, ok := scope.associationJoin(condition); ok {
+			from.Joins = append(from.Joins, join)
+			joinedColumns = append(joinedColumns, columns...)
+			continue
+		}
+
+		from.Joins = append(from.Joins, clause.Join{Expression: condition})
+	}
+
+	// columns of associations joined by name are selected unless columns are selected explicitly, e.g. by `Count`
+	if len(search.selects) > 0 {
+		selectQuery.Expression = search.selectExpr()
+	} else if len(search.joinConditions) > 0 {
+		selectQuery.Columns = append([]clause.Column{{Name: scope.QuotedTableName() + ".*", Raw: true}}, joinedColumns...)
+	}
+
+	return append([]clause.Interface{selectQuery, from}, scope.conditionClauses()...)
+}
+
+// conditionClauses populate clauses shared by queries and updates from the search, which are where, group by, order by and limit clauses
+func (scope *Scope) conditionClauses() []clause.Interface {
+	search := scope.Search
+	clauses := []clause.Interface{clause.Where{Exprs: scope.whereConditions()}}
+
+	if search.group != "" || len(search.havingConditions) > 0 {
+		groupBy := clause.GroupBy{}
+		if search.group != "" {
+			groupBy.Columns = []clause.Column{{Name: search.group, Raw: true}}
+		}
+		if len(search.havingConditions) > 0 {
+			groupBy.Having = []clause.Expression{searchConditions{ands: search.havingConditions}}
+		}
+		clauses = append(clauses, groupBy)
+	}
+
+	if len(search.orders) > 0 && !search.ignoreOrderQuery {
+		orderBy := clause.OrderBy{}
+		for _, order := range search.orders {
+			if str, ok := order.(string); ok {
+				orderBy.Columns = append(orderBy.Columns, clause.OrderByColumn{Column: clause.Column{Name: scope.quoteIfPossible(str), Raw: true}})
+			} else {
+				// orders with vars are built in place, so vars of other orders are added in order too
+				orderBy = clause.OrderBy{Expression: scope.ordersExpr()}
+				break
+			}
+		}
+		clauses = append(clauses, orderBy)
+	}
+
+	if limit, offset := parseLimit(search.limit), parseLimit(search.offset); limit > 0 || offset > 0 {
+		clauses = append(clauses, clause.Limit{Limit: limit, Offset: offset})
+	}
+	return clauses
+}
+
+// whereConditions return conditions of the where clause, which are the soft delete condition, conditions of primary keys and conditions of the search
+func (scope *Scope) whereConditions() (exprs []clause.Expression) {
+	search := scope.Search
+	if deletedAtField, ok := scope.FieldByName("DeletedAt"); ok && !search.Unscoped {
+		exprs = append(exprs, clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: deletedAtField.DBName}})
+	}
+
+	if !scope.PrimaryKeyZero() {
+		for _, field := range scope.PrimaryFields() {
+			exprs = append(exprs, clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName}, Value: field.Field.Interface()})
+		}
+	}
+
+	if len(search.WhereConditions) > 0 || len(search.NotConditions) > 0 || len(search.OrConditions) > 0 {
+		ands := append(append([]clause.Expression{}, search.WhereConditions...), search.NotConditions...)
+		exprs = append(exprs, searchConditions{ands: ands, ors: search.OrConditions})
+	}
+	return
+}
+
+// ordersExpr return orders of the search separated by commas, each order is a var of the expression, so orders with vars are built in place
+func (scope *Scope) ordersExpr() (expr clause.Expr) {
+	var placeholders []string
+	for _, order := range scope.Search.orders {
+		if str, ok := order.(string); ok {
+			expr.Vars = append(expr.Vars, clause.Column{Name: scope.quoteIfPossible(str), Raw: true})
+		} else if sqlExpr, ok := order.(*SQLExpr); ok {
+			expr.Vars = append(expr.Vars, clause.Expr{SQL: sqlExpr.expr, Vars: sqlExpr.args})
+		} else {
+			continue
+		}
+		placeholders = append(placeholders, "?")
+	}
+	expr.SQL = strings.Join(placeholders, ",")
+	return
+}
+
+// parseLimit parse limit or offset set with `Limit` or `Offset` which could be a number or a string, return 0 if it isn't set or invalid
+func parseLimit(value interface{}) int {
+	if value == nil {
+		return 0
+	}
+
+	parsed, err := strconv.ParseInt(fmt.Sprint(value), 0, 0)
+	if err != nil || parsed < 0 {
+		return 0
+	}
+	return int(parsed)
+}
+
+// updateClauses populate clauses of the update from assignments and its search
+func (scope *Scope) updateClauses(set clause.Set) []clause.Interface {
+	clauses := []clause.Interface{clause.Update{Table: clause.Table{Name: clause.CurrentTable}}, set}
+	return append(clauses, scope.conditionClauses()...)
+}
+
+// deleteClauses populate clauses of the delete from its search
+func (scope *Scope) deleteClauses() []clause.Interface {
+	return append([]clause.Interface{clause.Delete{Table: clause.Table{Name: clause.CurrentTable}}}, scope.conditionClauses()...)
+}
+
+// insertClauses populate clauses to insert rows of values of columns
+func (scope *Scope) insertClauses(columns []string, rows [][]interface{}) []clause.Interface {
+	values := clause.Values{Values: rows}
+	for _, column := range columns {
+		values.Columns = append(values.Columns, clause.Column{Name: column})
+	}
+	return []clause.Interface{clause.Insert{Table: clause.Table{Name: clause.CurrentTable}}, values}
+}
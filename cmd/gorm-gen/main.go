@@ -0,0 +1,66 @@
+// Command gorm-gen generates model structs from tables of an existing database, e.g:
+//     gorm-gen -dialect postgres -dsn "host=localhost user=gorm dbname=gorm sslmode=disable" -package models -out models/models.go
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/mssql"
+	_ "github.com/jinzhu/gorm/dialects/mysql"
+	_ "github.com/jinzhu/gorm/dialects/postgres"
+	_ "github.com/jinzhu/gorm/dialects/sqlite"
+	"github.com/jinzhu/gorm/gen"
+)
+
+func main() {
+	var (
+		dialect     = flag.String("dialect", "sqlite3", "dialect of the database: mysql, postgres, sqlite3 or mssql")
+		dsn         = flag.String("dsn", "", "data source name of the database")
+		packageName = flag.String("package", "models", "package of generated code")
+		tables      = flag.String("tables", "", "comma separated tables to generate models for, defaults to all tables")
+		out         = flag.String("out", "", "file to write generated code to, defaults to stdout")
+	)
+	flag.Parse()
+
+	if *dsn == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	db, err := gorm.Open(*dialect, *dsn)
+	if err != nil {
+		exit(err)
+	}
+	defer db.Close()
+
+	config := gen.Config{PackageName: *packageName}
+	for _, table := range strings.Split(*tables, ",") {
+		if table = strings.TrimSpace(table); table != "" {
+			config.Tables = append(config.Tables, table)
+		}
+	}
+
+	source, err := gen.Generate(db, config)
+	if err != nil {
+		exit(err)
+	}
+
+	if *out == "" {
+		_, err = os.Stdout.Write(source)
+	} else {
+		err = ioutil.WriteFile(*out, source, 0644)
+	}
+	if err != nil {
+		exit(err)
+	}
+}
+
+func exit(err error) {
+	fmt.Fprintln(os.Stderr, "gorm-gen:", err)
+	os.Exit(1)
+}
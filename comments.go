@@ -0,0 +1,37 @@
+package gorm
+
+import "reflect"
+
+// tableComment return the comment of the model's table returned by its `TableComment` method, e.g:
+//     func (User) TableComment() string { return "registered users" }
+func (scope *Scope) tableComment() string {
+	if modelType := scope.GetModelStruct().ModelType; modelType != nil {
+		if commenter, ok := reflect.New(modelType).Interface().(interface {
+			TableComment() string
+		}); ok {
+			return commenter.TableComment()
+		}
+	}
+	return ""
+}
+
+// updateComments set comments of the table and its columns declared with tag `comment` which are different from current ones,
+// comments removed from the model are kept in the database
+func (scope *Scope) updateComments(tableComment string, columnComments map[string]string) {
+	tableName := scope.TableName()
+	if comment := scope.tableComment(); comment != "" && comment != tableComment {
+		if sql := scope.Dialect().TableCommentSQL(tableName, comment); sql != "" {
+			scope.planReason("comment on table `%v`", tableName)
+			scope.Raw(sql).Exec()
+		}
+	}
+
+	for _, field := range scope.GetModelStruct().StructFields {
+		if comment, ok := field.TagSettings["COMMENT"]; ok && field.IsNormal && comment != columnComments[field.DBName] {
+			if sql := scope.Dialect().ColumnCommentSQL(tableName, field.DBName, scope.Dialect().DataTypeOf(field), comment); sql != "" {
+				scope.planReason("comment on column `%v` of `%v`", field.DBName, tableName)
+				scope.Raw(sql).Exec()
+			}
+		}
+	}
+}
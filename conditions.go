@@ -0,0 +1,276 @@
+package gorm
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/jinzhu/gorm/clause"
+)
+
+// primaryKeyColumn the primary key of the statement's table, it's resolved when the statement is built, so conditions could be added before the model is known
+var primaryKeyColumn = clause.Column{Table: clause.CurrentTable, Name: clause.PrimaryKey}
+
+// toCondition convert the condition passed to `Where` or `Or` to an expression, for example:
+//     "name = ? AND age > ?", "jinzhu", 18  // clause.Expr{SQL: "name = ? AND age > ?", Vars: []interface{}{"jinzhu", 18}}
+//     10                                    // clause.Eq{Column: primary key, Value: 10}
+//     []int{10, 11}                         // clause.IN{Column: primary key, Values: []interface{}{10, 11}}
+//     map[string]interface{}{"age": 18}     // clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: "age"}, Value: 18}
+// structs are converted like maps of their non blank fields, queries like `db.Where("a").Or("b")` are converted to their conditions,
+// conditions of multiple columns are combined with `clause.And`, expressions are used as is
+func toCondition(query interface{}, args []interface{}) clause.Expression {
+	switch value := query.(type) {
+	case clause.Expression:
+		return value
+	case *SQLExpr:
+		return namedExpr(value.expr, value.args)
+	case string:
+		if isNumberRegexp.MatchString(value) {
+			return clause.Eq{Column: primaryKeyColumn, Value: value}
+		}
+		return namedExpr(value, args)
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, sql.NullInt64:
+		return clause.Eq{Column: primaryKeyColumn, Value: value}
+	case []int, []int8, []int16, []int32, []int64, []uint, []uint8, []uint16, []uint32, []uint64, []string, []interface{}:
+		return clause.IN{Column: primaryKeyColumn, Values: toValues(value)}
+	case *DB:
+		return value.search.conditions()
+	case map[string]interface{}:
+		columns, values := mapColumns(value)
+		return columnConditions(columns, values, false)
+	default:
+		columns, values := structColumns(value)
+		return columnConditions(columns, values, false)
+	}
+}
+
+// toNotCondition convert the condition passed to `Not` to an expression, strings without comparisons are column names compared with args, for example:
+//     "name", []string{"jinzhu", "jinzhu 2"} // clause.Not(clause.IN{Column: clause.Column{Table: clause.CurrentTable, Name: "name"}, Values: ...})
+//     "name", "jinzhu"                        // clause.Neq{Column: clause.Column{Table: clause.CurrentTable, Name: "name"}, Value: "jinzhu"}
+// columns of other conditions are compared with `clause.Neq`, queries and expressions are negated with `clause.Not`
+func toNotCondition(query interface{}, args []interface{}) clause.Expression {
+	switch value := query.(type) {
+	case string:
+		if isNumberRegexp.MatchString(value) {
+			return clause.Neq{Column: primaryKeyColumn, Value: value}
+		} else if comparisonRegexp.MatchString(value) {
+			return clause.Not(namedExpr(value, args))
+		}
+
+		var (
+			column = clause.Column{Table: clause.CurrentTable, Name: value}
+			arg    interface{}
+		)
+		if len(args) > 0 {
+			arg = args[0]
+		}
+		if reflectValue := reflect.ValueOf(arg); reflectValue.Kind() == reflect.Slice && reflectValue.Type().Elem().Kind() != reflect.Uint8 {
+			return clause.Not(clause.IN{Column: column, Values: toValues(arg)})
+		}
+		return clause.Neq{Column: column, Value: arg}
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, sql.NullInt64:
+		return clause.Neq{Column: primaryKeyColumn, Value: value}
+	case []int, []int8, []int16, []int32, []int64, []uint, []uint8, []uint16, []uint32, []uint64, []string, []interface{}:
+		if reflect.ValueOf(value).Len() == 0 {
+			return clause.And()
+		}
+		return clause.Not(clause.IN{Column: primaryKeyColumn, Values: toValues(value)})
+	case clause.Expression, *SQLExpr, *DB:
+		return clause.Not(toCondition(value, args))
+	case map[string]interface{}:
+		columns, values := mapColumns(value)
+		return columnConditions(columns, values, true)
+	default:
+		columns, values := structColumns(value)
+		return columnConditions(columns, values, true)
+	}
+}
+
+// toConditions convert conditions of groups created with `And` or `Or` to expressions
+func toConditions(conditions []interface{}) []clause.Expression {
+	exprs := make([]clause.Expression, len(conditions))
+	for idx, condition := range conditions {
+		exprs[idx] = toCondition(condition, nil)
+	}
+	return exprs
+}
+
+// columnConditions conditions that columns of the table equal to values, or don't equal to values if negated, combined with AND
+func columnConditions(columns []string, values []interface{}, negated bool) clause.Expression {
+	exprs := make([]clause.Expression, len(columns))
+	for idx, column := range columns {
+		eq := clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: column}, Value: values[idx]}
+		if negated {
+			exprs[idx] = clause.Neq(eq)
+		} else {
+			exprs[idx] = eq
+		}
+	}
+
+	if len(exprs) == 1 {
+		return exprs[0]
+	}
+	return clause.And(exprs...)
+}
+
+// mapColumns return columns of the map sorted by names and their values
+func mapColumns(value map[string]interface{}) (columns []string, values []interface{}) {
+	for column := range value {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	for _, column := range columns {
+		values = append(values, value[column])
+	}
+	return
+}
+
+// structColumns return columns of non blank fields of the struct and their values
+func structColumns(value interface{}) (columns []string, values []interface{}) {
+	for _, field := range (&Scope{Value: value}).Fields() {
+		if !field.IsIgnored && !field.IsBlank {
+			columns = append(columns, field.DBName)
+			values = append(values, field.Field.Interface())
+		}
+	}
+	return
+}
+
+// toValues return elements of the slice
+func toValues(slice interface{}) []interface{} {
+	reflectValue := reflect.ValueOf(slice)
+	values := make([]interface{}, reflectValue.Len())
+	for i := range values {
+		values[i] = reflectValue.Index(i).Interface()
+	}
+	return values
+}
+
+// conditionValues return values of columns of the table compared with equal conditions, e.g. conditions of maps or structs,
+// which are used to initialize records not found by `FirstOrInit`
+func conditionValues(expr clause.Expression) map[string]interface{} {
+	values := map[string]interface{}{}
+	switch expr := expr.(type) {
+	case clause.Eq:
+		if column, ok := expr.Column.(clause.Column); ok && column.Table == clause.CurrentTable && column.Name != clause.PrimaryKey {
+			values[column.Name] = expr.Value
+		}
+	case clause.AndConditions:
+		for _, expr := range expr.Exprs {
+			for column, value := range conditionValues(expr) {
+				values[column] = value
+			}
+		}
+	}
+	return values
+}
+
+// conditions where and not conditions of the search combined with AND, then or conditions combined with OR
+func (s *search) conditions() clause.Expression {
+	ands := append(append([]clause.Expression{}, s.WhereConditions...), s.NotConditions...)
+	if len(s.OrConditions) == 0 {
+		return clause.And(ands...)
+	} else if len(ands) == 0 {
+		return clause.Or(s.OrConditions...)
+	}
+	return clause.Or(append([]clause.Expression{clause.And(ands...)}, s.OrConditions...)...)
+}
+
+// namedExpr convert raw sql with its args to an expression, named args are bound to placeholders in order, refer `namedArgs`
+func namedExpr(query string, args []interface{}) clause.Expr {
+	if namedArgs, positionalArgs, ok := namedArgs(query, args); ok {
+		return bindNamedArgs(query, namedArgs, positionalArgs)
+	}
+	return clause.Expr{SQL: query, Vars: args}
+}
+
+// namedArgs return values of named args by their names and other args if args have `sql.NamedArg`s, or a map or a struct used by a query without `?`, e.g:
+//     db.Where("name = @name OR nickname = @name", sql.Named("name", "jinzhu"))
+//     db.Where("age > ? AND (name = @name OR nickname = @name)", 18, sql.Named("name", "jinzhu"))
+//     db.Where("name = @name AND age > @age", map[string]interface{}{"name": "jinzhu", "age": 18})
+//     db.Where("name = @Name AND age > @age", User{Name: "jinzhu", Age: 18})
+// fields of structs could be referred to by their names or column names
+func namedArgs(query string, args []interface{}) (namedArgs map[string]interface{}, positionalArgs []interface{}, ok bool) {
+	namedArgs = map[string]interface{}{}
+	for _, arg := range args {
+		if namedArg, ok := arg.(sql.NamedArg); ok {
+			namedArgs[namedArg.Name] = namedArg.Value
+		} else {
+			positionalArgs = append(positionalArgs, arg)
+		}
+	}
+	if len(namedArgs) > 0 {
+		return namedArgs, positionalArgs, true
+	}
+
+	if len(args) != 1 || strings.Contains(query, "?") {
+		return nil, args, false
+	}
+
+	switch arg := args[0].(type) {
+	case map[string]interface{}:
+		return arg, nil, true
+	case *DB, *SQLExpr, clause.Expression, driver.Valuer, time.Time, *time.Time:
+		return nil, args, false
+	}
+
+	if reflect.Indirect(reflect.ValueOf(args[0])).Kind() == reflect.Struct {
+		for _, field := range (&Scope{Value: args[0]}).Fields() {
+			namedArgs[field.Name] = field.Field.Interface()
+			namedArgs[field.DBName] = field.Field.Interface()
+		}
+		return namedArgs, nil, true
+	}
+	return nil, args, false
+}
+
+// bindNamedArgs replace `@name` placeholders outside quoted strings with `?` bound to values of named args, and bind `?` to positional args in order,
+// unknown names and variables like mysql's `@@version` are kept, so are question marks in quoted strings or without args, which are bound to themselves
+func bindNamedArgs(query string, namedArgs map[string]interface{}, positionalArgs []interface{}) (expr clause.Expr) {
+	var (
+		buf   bytes.Buffer
+		quote byte
+		idx   int
+	)
+
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case c == '?':
+			if quote == 0 && idx < len(positionalArgs) {
+				expr.Vars = append(expr.Vars, positionalArgs[idx])
+				idx++
+			} else {
+				expr.Vars = append(expr.Vars, clause.Expr{SQL: "?"})
+			}
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '@' && (i == 0 || query[i-1] != '@'):
+			j := i + 1
+			for j < len(query) && (query[j] == '_' || unicode.IsLetter(rune(query[j])) || unicode.IsDigit(rune(query[j]))) {
+				j++
+			}
+
+			if value, ok := namedArgs[query[i+1:j]]; ok && j > i+1 {
+				buf.WriteByte('?')
+				expr.Vars = append(expr.Vars, value)
+				i = j - 1
+				continue
+			}
+		}
+		buf.WriteByte(c)
+	}
+
+	expr.SQL = buf.String()
+	return
+}
@@ -0,0 +1,222 @@
+package gorm
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// foreignKeyConstraint foreign key constraint built from a relationship, created by `CreateTable` and `AutoMigrate`
+type foreignKeyConstraint struct {
+	name       string
+	table      string
+	columns    []string
+	refTable   string
+	refColumns []string
+	onDelete   string
+	onUpdate   string
+}
+
+// Check check constraint of a model, returned by the model's `CheckConstraints` method, e.g:
+//     func (User) CheckConstraints() []gorm.Check {
+//       return []gorm.Check{{Name: "chk_users_age_limit", Constraint: "age < max_age"}}
+//     }
+type Check struct {
+	// Name name of the constraint, defaults to `chk_<table>_<index>`
+	Name string
+	// Constraint condition of the constraint, e.g. `age >= 0`
+	Constraint string
+}
+
+// DisableForeignKeyConstraints stop creating foreign key constraints from relationships when creating or migrating tables
+func (s *DB) DisableForeignKeyConstraints(disable bool) {
+	s.parent.Lock()
+	defer s.parent.Unlock()
+	s.parent.disableForeignKeyConstraints = disable
+}
+
+// foreignKeyConstraints return foreign key constraints of relationships of the models, constraints are created after all tables are created,
+// so a model could be migrated before the models it references
+func (s *DB) foreignKeyConstraints(models ...interface{}) (constraints []foreignKeyConstraint) {
+	s.parent.RLock()
+	disable := s.parent.disableForeignKeyConstraints
+	s.parent.RUnlock()
+	if disable {
+		return nil
+	}
+
+	names := map[string]bool{}
+	for _, model := range models {
+		for _, constraint := range s.NewScope(model).foreignKeyConstraints() {
+			if !names[constraint.name] {
+				names[constraint.name] = true
+				constraints = append(constraints, constraint)
+			}
+		}
+	}
+	return constraints
+}
+
+// foreignKeyConstraints return foreign key constraints of the model's relationships, polymorphic relationships don't have constraints as they reference several tables,
+// actions are set with tag `constraint`, e.g. `gorm:"constraint:OnDelete:CASCADE,OnUpdate:SET NULL"`
+func (scope *Scope) foreignKeyConstraints() (constraints []foreignKeyConstraint) {
+	tableName := scope.TableName()
+	for _, field := range scope.GetModelStruct().StructFields {
+		relationship := field.Relationship
+		if relationship == nil || relationship.PolymorphicType != "" {
+			continue
+		}
+
+		var (
+			toTableName        = scope.New(reflect.New(field.Struct.Type).Interface()).TableName()
+			onDelete, onUpdate = parseConstraintActions(field.TagSettings["CONSTRAINT"])
+		)
+
+		switch relationship.Kind {
+		case "belongs_to":
+			constraints = append(constraints, scope.newForeignKeyConstraint(tableName, relationship.ForeignDBNames, toTableName, relationship.AssociationForeignDBNames, onDelete, onUpdate))
+		case "has_one", "has_many":
+			constraints = append(constraints, scope.newForeignKeyConstraint(toTableName, relationship.ForeignDBNames, tableName, relationship.AssociationForeignDBNames, onDelete, onUpdate))
+		case "many_to_many":
+			joinTable := relationship.JoinTableHandler.Table(scope.db)
+			constraints = append(constraints,
+				scope.newForeignKeyConstraint(joinTable, relationship.ForeignDBNames, tableName, relationship.ForeignFieldNames, onDelete, onUpdate),
+				scope.newForeignKeyConstraint(joinTable, relationship.AssociationForeignDBNames, toTableName, relationship.AssociationForeignFieldNames, onDelete, onUpdate),
+			)
+		}
+	}
+	return constraints
+}
+
+func (scope *Scope) newForeignKeyConstraint(table string, columns []string, refTable string, refColumns []string, onDelete, onUpdate string) foreignKeyConstraint {
+	dest := fmt.Sprintf("%v(%v)", refTable, strings.Join(refColumns, ","))
+	return foreignKeyConstraint{
+		name:       scope.Dialect().BuildForeignKeyName(table, strings.Join(columns, "_"), dest),
+		table:      table,
+		columns:    columns,
+		refTable:   refTable,
+		refColumns: refColumns,
+		onDelete:   onDelete,
+		onUpdate:   onUpdate,
+	}
+}
+
+// parseConstraintActions parse actions from the value of tag `constraint`, e.g. `OnDelete:CASCADE,OnUpdate:SET NULL`
+func parseConstraintActions(value string) (onDelete, onUpdate string) {
+	for _, action := range strings.Split(value, ",") {
+		if kv := strings.SplitN(action, ":", 2); len(kv) == 2 {
+			switch strings.ToUpper(strings.TrimSpace(kv[0])) {
+			case "ONDELETE":
+				onDelete = strings.TrimSpace(kv[1])
+			case "ONUPDATE":
+				onUpdate = strings.TrimSpace(kv[1])
+			}
+		}
+	}
+	return
+}
+
+// definition return sql to define the constraint in `CREATE TABLE` or `ALTER TABLE ADD`
+func (constraint foreignKeyConstraint) definition(scope *Scope) string {
+	var columns, refColumns []string
+	for _, column := range constraint.columns {
+		columns = append(columns, scope.Quote(column))
+	}
+	for _, column := range constraint.refColumns {
+		refColumns = append(refColumns, scope.Quote(column))
+	}
+
+	sql := fmt.Sprintf("CONSTRAINT %v FOREIGN KEY (%v) REFERENCES %v (%v)", scope.Quote(constraint.name), strings.Join(columns, ","), scope.Quote(constraint.refTable), strings.Join(refColumns, ","))
+	if constraint.onDelete != "" {
+		sql += " ON DELETE " + constraint.onDelete
+	}
+	if constraint.onUpdate != "" {
+		sql += " ON UPDATE " + constraint.onUpdate
+	}
+	return sql
+}
+
+// inlineForeignKeyConstraints return definitions of constraints on the table, used by dialects can't add constraints to existing tables
+func (scope *Scope) inlineForeignKeyConstraints(tableName string, constraints []foreignKeyConstraint) (definitions []string) {
+	if !scope.Dialect().InlineForeignKeys() {
+		return nil
+	}
+
+	for _, constraint := range constraints {
+		if constraint.table == tableName {
+			definitions = append(definitions, constraint.definition(scope))
+		}
+	}
+	return definitions
+}
+
+// addForeignKeyConstraints add constraints whose tables exist and haven't been added,
+// skipped if the dialect defines constraints in `CREATE TABLE`
+func (scope *Scope) addForeignKeyConstraints(constraints []foreignKeyConstraint) *Scope {
+	if scope.Dialect().InlineForeignKeys() {
+		return scope
+	}
+
+	for _, constraint := range constraints {
+		if scope.hasTable(constraint.table) && scope.hasTable(constraint.refTable) && !scope.Dialect().HasForeignKey(constraint.table, constraint.name) {
+			scope.planReason("add foreign key `%v` to `%v` referencing `%v`", constraint.name, constraint.table, constraint.refTable)
+			scope.Raw(fmt.Sprintf("ALTER TABLE %v ADD %v", scope.Quote(constraint.table), constraint.definition(scope))).Exec()
+		}
+	}
+	return scope
+}
+
+// checkConstraints return check constraints of the model, declared with tag `check` on fields, e.g. `gorm:"check:age >= 0"`,
+// which are named `chk_<table>_<column>`, or returned by the model's `CheckConstraints` method
+func (scope *Scope) checkConstraints() (checks []Check) {
+	tableName := scope.TableName()
+	for _, field := range scope.GetModelStruct().StructFields {
+		if constraint, ok := field.TagSettings["CHECK"]; ok && field.IsNormal {
+			checks = append(checks, Check{Name: fmt.Sprintf("chk_%v_%v", tableName, field.DBName), Constraint: constraint})
+		}
+	}
+
+	if modelType := scope.GetModelStruct().ModelType; modelType != nil {
+		if checker, ok := reflect.New(modelType).Interface().(interface {
+			CheckConstraints() []Check
+		}); ok {
+			for idx, check := range checker.CheckConstraints() {
+				if check.Name == "" {
+					check.Name = fmt.Sprintf("chk_%v_%d", tableName, idx)
+				}
+				checks = append(checks, check)
+			}
+		}
+	}
+	return checks
+}
+
+// definition return sql to define the check constraint in `CREATE TABLE`
+func (check Check) definition(scope *Scope) string {
+	return fmt.Sprintf("CONSTRAINT %v CHECK (%v)", scope.Quote(check.Name), check.Constraint)
+}
+
+// addCheckConstraints add check constraints haven't been added to the table,
+// returns false if the dialect can't add constraints to existing tables and the table needs to be recreated
+func (scope *Scope) addCheckConstraints() bool {
+	tableName := scope.TableName()
+	for _, check := range scope.checkConstraints() {
+		if !scope.Dialect().HasConstraint(tableName, check.Name) {
+			scope.planReason("add check constraint `%v` to `%v`", check.Name, tableName)
+			sql, err := scope.Dialect().CreateConstraintSQL(tableName, check.Name, fmt.Sprintf("CHECK (%v)", check.Constraint))
+			if err == ErrUnsupportedConstraint {
+				return false
+			}
+			if scope.Err(err) == nil {
+				scope.Raw(sql).Exec()
+			}
+		}
+	}
+	return true
+}
+
+func (scope *Scope) dropConstraint(constraintName string) {
+	if sql, err := scope.Dialect().DropConstraintSQL(scope.TableName(), constraintName); scope.Err(err) == nil {
+		scope.Raw(sql).Exec()
+	}
+}
@@ -1,10 +1,14 @@
 package gorm_test
 
 import (
+	"fmt"
 	"os"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/jinzhu/gorm"
 )
 
 func TestCreate(t *testing.T) {
@@ -186,3 +190,247 @@ func TestOmitWithCreate(t *testing.T) {
 		t.Errorf("Should not create omitted relationships")
 	}
 }
+
+type sqlCounter struct {
+	inserts int
+}
+
+func (c *sqlCounter) Print(values ...interface{}) {
+	if len(values) > 3 && values[0] == "sql" {
+		if sql, ok := values[3].(string); ok && strings.HasPrefix(sql, "INSERT INTO") {
+			c.inserts++
+		}
+	}
+}
+
+func TestCreateSlice(t *testing.T) {
+	users := []User{
+		{Name: "BatchUser1", Age: 1, BillingAddress: Address{Address1: "BatchUser1 Billing Address"}},
+		{Name: "BatchUser2", Age: 2, Emails: []Email{{Email: "batch_user2@example.com"}}},
+		{Name: "BatchUser3", Age: 3},
+	}
+
+	if count := DB.Create(&users).RowsAffected; count != 3 {
+		t.Errorf("There should be three records be affected when create slice, but got %v", count)
+	}
+
+	for idx, user := range users {
+		if user.Id == 0 {
+			t.Errorf("Primary key should be set after create slice")
+		}
+
+		if user.CreatedAt.IsZero() {
+			t.Errorf("Should have created_at after create slice")
+		}
+
+		var newUser User
+		if err := DB.First(&newUser, user.Id).Error; err != nil || newUser.Name != user.Name || newUser.Age != int64(idx+1) {
+			t.Errorf("Should find created user %v by primary key, but got %+v", user.Name, newUser)
+		}
+	}
+
+	if users[0].BillingAddressID.Int64 == 0 || DB.First(&Address{}, users[0].BillingAddressID.Int64).RecordNotFound() {
+		t.Errorf("Belongs to associations should be saved when create slice")
+	}
+
+	var emails []Email
+	if DB.Where("user_id = ?", users[1].Id).Find(&emails); len(emails) != 1 {
+		t.Errorf("Has many associations should be saved when create slice")
+	}
+}
+
+func TestCreateSliceInBatches(t *testing.T) {
+	var users []*User
+	for i := 0; i < 5; i++ {
+		users = append(users, &User{Name: fmt.Sprintf("BatchSizeUser%v", i)})
+	}
+
+	counter := &sqlCounter{}
+	db := DB.New()
+	db.SetLogger(counter)
+	db.LogMode(true)
+
+	if count := db.Set("gorm:batch_size", 2).Create(&users).RowsAffected; count != 5 {
+		t.Errorf("There should be five records be affected when create slice, but got %v", count)
+	}
+
+	if counter.inserts != 3 {
+		t.Errorf("Should insert five records with three statements, but got %v", counter.inserts)
+	}
+
+	var names []string
+	DB.Model(&User{}).Where("name LIKE ?", "BatchSizeUser%").Order("id").Pluck("name", &names)
+	if len(names) != 5 {
+		t.Errorf("Should find all created records, but got %v", names)
+	}
+
+	for _, user := range users {
+		var newUser User
+		if DB.First(&newUser, user.Id); newUser.Name != user.Name {
+			t.Errorf("Primary key of %v should be set after create in batches, but got %+v", user.Name, newUser)
+		}
+	}
+}
+
+func TestCreateSliceWithCallbacks(t *testing.T) {
+	products := []Product{{Code: "batch_code1", Price: 10}, {Code: "batch_code2", Price: 20}}
+	DB.Create(&products)
+
+	for _, product := range products {
+		if product.BeforeCreateCallTimes != 1 || product.BeforeSaveCallTimes != 1 || product.AfterSaveCallTimes != 1 {
+			t.Errorf("Callbacks should be invoked for each element, but got %v", product.GetCallTimes())
+		}
+
+		var newProduct Product
+		DB.First(&newProduct, product.Id)
+		if newProduct.Code != product.Code || newProduct.AfterCreateCallTimes != 1 {
+			t.Errorf("AfterCreate should be invoked for each element, but got %v", newProduct.GetCallTimes())
+		}
+	}
+
+	invalidProducts := []Product{{Code: "batch_code3"}, {Code: "Invalid"}}
+	if err := DB.Create(&invalidProducts).Error; err == nil {
+		t.Errorf("Should get error when BeforeCreate of an element fails")
+	}
+
+	if !DB.Where("code = ?", "batch_code3").First(&Product{}).RecordNotFound() {
+		t.Errorf("Should not create any record when BeforeCreate of an element fails")
+	}
+}
+
+type UpsertUser struct {
+	ID        uint
+	Email     string `sql:"size:100;unique_index"`
+	Name      string
+	Age       int
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func TestCreateOnConflict(t *testing.T) {
+	DB.DropTableIfExists(&UpsertUser{})
+	if err := DB.AutoMigrate(&UpsertUser{}).Error; err != nil {
+		t.Fatalf("Failed to migrate upsert users, got %v", err)
+	}
+
+	user := UpsertUser{Email: "upsert@example.com", Name: "upsert", Age: 18}
+	DB.Create(&user)
+
+	user2 := UpsertUser{Email: "upsert@example.com", Name: "upsert2", Age: 20}
+	if err := DB.Set("gorm:on_conflict", gorm.OnConflict{Columns: []string{"Email"}, UpdateColumns: []string{"Name"}}).Create(&user2).Error; err != nil {
+		t.Errorf("No error should happen when upserting, but got %v", err)
+	}
+
+	var result UpsertUser
+	DB.First(&result, user.ID)
+	if user2.ID != user.ID || result.Name != "upsert2" || result.Age != 18 {
+		t.Errorf("Should only update name of the conflicted record, but got %+v", result)
+	}
+
+	user3 := UpsertUser{Email: "upsert@example.com", Name: "upsert3", Age: 30}
+	DB.Set("gorm:on_conflict", &gorm.OnConflict{Columns: []string{"email"}, UpdateAll: true}).Create(&user3)
+	DB.First(&result, user.ID)
+	if user3.ID != user.ID || result.Name != "upsert3" || result.Age != 30 {
+		t.Errorf("Should update all columns of the conflicted record, but got %+v", result)
+	}
+
+	if !result.CreatedAt.Equal(user.CreatedAt) {
+		t.Errorf("Should not update created_at of the conflicted record")
+	}
+
+	user4 := UpsertUser{Email: "upsert@example.com", Name: "upsert4"}
+	DB.Set("gorm:on_conflict", gorm.OnConflict{Columns: []string{"email"}, DoNothing: true}).Create(&user4)
+	DB.First(&result, user.ID)
+	if user4.ID != user.ID || result.Name != "upsert3" {
+		t.Errorf("Should skip the conflicted record and set its primary key, but got %+v", result)
+	}
+
+	if dialect := os.Getenv("GORM_DIALECT"); dialect != "mysql" {
+		user5 := UpsertUser{Email: "upsert@example.com", Name: "upsert5"}
+		DB.Set("gorm:on_conflict", gorm.OnConflict{Columns: []string{"email"}, UpdateColumns: []string{"name"}, Where: gorm.Expr("upsert_users.age < ?", 20)}).Create(&user5)
+		if DB.First(&result, user.ID); result.Name != "upsert3" {
+			t.Errorf("Should not update the conflicted record not matching the condition, but got %+v", result)
+		}
+	}
+
+	users := []UpsertUser{{Email: "upsert@example.com", Name: "upsert6", Age: 60}, {Email: "upsert2@example.com", Name: "upsert7", Age: 70}}
+	if err := DB.Set("gorm:on_conflict", gorm.OnConflict{Columns: []string{"email"}, UpdateAll: true}).Create(&users).Error; err != nil {
+		t.Errorf("No error should happen when upserting slice, but got %v", err)
+	}
+
+	if users[0].ID != user.ID || users[1].ID == 0 || users[1].ID == user.ID {
+		t.Errorf("Should set primary keys of upserted records, but got %+v", users)
+	}
+
+	var count int
+	if DB.Model(&UpsertUser{}).Count(&count); count != 2 {
+		t.Errorf("Should have two records after upserting, but got %v", count)
+	}
+
+	for _, u := range users {
+		var result UpsertUser
+		if DB.First(&result, u.ID); result.Name != u.Name || result.Age != u.Age {
+			t.Errorf("Upserted record should be saved, but got %+v", result)
+		}
+	}
+}
+
+func TestCreateOnConflictDoNothing(t *testing.T) {
+	DB.DropTableIfExists(&UpsertUser{})
+	DB.AutoMigrate(&UpsertUser{})
+
+	user := UpsertUser{Email: "do_nothing@example.com", Name: "do_nothing"}
+	DB.Create(&user)
+
+	// the conflict target defaults to the unique index on email
+	users := []UpsertUser{{Email: "do_nothing@example.com", Name: "do_nothing2"}, {Email: "do_nothing3@example.com", Name: "do_nothing3"}}
+	if err := DB.Set("gorm:on_conflict", gorm.OnConflict{DoNothing: true}).Create(&users).Error; err != nil {
+		t.Errorf("No error should happen when skipping conflicted records, but got %v", err)
+	}
+
+	if users[0].ID != user.ID || users[1].ID == 0 || users[1].ID == user.ID {
+		t.Errorf("Should set primary keys of skipped and inserted records, but got %+v", users)
+	}
+
+	var result UpsertUser
+	if DB.First(&result, user.ID); result.Name != "do_nothing" {
+		t.Errorf("Should skip the conflicted record, but got %+v", result)
+	}
+
+	type ConflictTargetlessUser struct {
+		Name string
+	}
+	DB.DropTableIfExists(&ConflictTargetlessUser{})
+	DB.AutoMigrate(&ConflictTargetlessUser{})
+	if err := DB.Set("gorm:on_conflict", gorm.OnConflict{DoNothing: true}).Create(&ConflictTargetlessUser{Name: "targetless"}).Error; err != gorm.ErrMissingConflictColumns {
+		t.Errorf("Should return error when there is no conflict target, but got %v", err)
+	}
+}
+
+type CollationUpsertUser struct {
+	ID    uint
+	Email string `gorm:"type:varchar(100) COLLATE NOCASE;unique_index"`
+	Name  string
+}
+
+func TestCreateOnConflictWithCollation(t *testing.T) {
+	if dialect := os.Getenv("GORM_DIALECT"); dialect != "" && dialect != "sqlite" {
+		t.Skip("Skipping this because collations are named differently by dbs")
+	}
+
+	DB.DropTableIfExists(&CollationUpsertUser{})
+	DB.AutoMigrate(&CollationUpsertUser{})
+
+	user := CollationUpsertUser{Email: "collation@example.com", Name: "collation"}
+	DB.Create(&user)
+
+	// conflicted records are matched as the db compares them, the email conflicts case insensitively
+	users := []CollationUpsertUser{{Email: "Collation@Example.com", Name: "collation2"}, {Email: "collation3@example.com", Name: "collation3"}}
+	if err := DB.Clauses(gorm.OnConflict{DoNothing: true}).Create(&users).Error; err != nil {
+		t.Errorf("No error should happen when skipping conflicted records, but got %v", err)
+	}
+
+	if users[0].ID != user.ID || users[1].ID == 0 || users[1].ID == user.ID {
+		t.Errorf("Should set primary keys of records conflicted case insensitively, but got %+v", users)
+	}
+}
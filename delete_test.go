@@ -3,6 +3,9 @@ package gorm_test
 import (
 	"testing"
 	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/jinzhu/gorm/clause"
 )
 
 func TestDelete(t *testing.T) {
@@ -89,3 +92,48 @@ func TestSoftDeleteWithCustomizedDeletedAtColumnName(t *testing.T) {
 		t.Errorf("Can't find permanently deleted record")
 	}
 }
+
+func TestDeleteWithClauses(t *testing.T) {
+	type ClauseDeleteUser struct {
+		Id   int64
+		Name string
+		Age  int
+	}
+	DB.DropTableIfExists(&ClauseDeleteUser{})
+	DB.AutoMigrate(&ClauseDeleteUser{})
+	DB.Save(&ClauseDeleteUser{Name: "clause_delete", Age: 10})
+	DB.Save(&ClauseDeleteUser{Name: "clause_delete", Age: 20})
+
+	if err := DB.Clauses(clause.Where{Exprs: []clause.Expression{clause.Eq{Column: "age", Value: 10}}}).Delete(&ClauseDeleteUser{}).Error; err != nil {
+		t.Errorf("No error should happen when delete with where clauses, but got %v", err)
+	}
+
+	var count int
+	if DB.Model(&ClauseDeleteUser{}).Where("age = ?", 20).Count(&count); count != 1 {
+		t.Errorf("Other records should be kept when delete with where clauses, but got %v", count)
+	}
+
+	if DB.Model(&ClauseDeleteUser{}).Where("age = ?", 10).Count(&count); count != 0 {
+		t.Errorf("Records matched the where clauses should be deleted, but got %v", count)
+	}
+
+	// soft delete is an update, which should keep the where clauses too
+	card1, card2 := CreditCard{Number: "clause_soft_delete1"}, CreditCard{Number: "clause_soft_delete2"}
+	DB.Save(&card1)
+	DB.Save(&card2)
+	DB.Clauses(clause.Where{Exprs: []clause.Expression{clause.Eq{Column: "number", Value: card1.Number}}}).Delete(&CreditCard{})
+	if !DB.First(&CreditCard{}, "number = ?", card1.Number).RecordNotFound() {
+		t.Errorf("Records matched the where clauses should be soft deleted")
+	}
+	if DB.First(&CreditCard{}, "number = ?", card2.Number).RecordNotFound() {
+		t.Errorf("Other records should be kept when soft delete with where clauses")
+	}
+
+	if err := DB.New().BlockGlobalUpdate(true).Clauses(clause.Where{Exprs: []clause.Expression{clause.Eq{Column: "age", Value: 20}}}).Delete(&ClauseDeleteUser{}).Error; err != nil {
+		t.Errorf("Where clauses should be conditions of blocked global deletes, but got %v", err)
+	}
+
+	if err := DB.Raw("SELECT * FROM users").Clauses(clause.Limit{Limit: 1}).Scan(&[]User{}).Error; err != gorm.ErrUnsupportedClause {
+		t.Errorf("Should return error when use clauses with raw sql, but got %v", err)
+	}
+}
@@ -2,10 +2,15 @@ package gorm
 
 import (
 	"database/sql"
+	"database/sql/driver"
 	"fmt"
 	"reflect"
+	"regexp"
 	"strconv"
 	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm/clause"
 )
 
 // Dialect interface contains behaviors that differ across SQL database
@@ -20,6 +25,8 @@ type Dialect interface {
 	BindVar(i int) string
 	// Quote quotes field name to avoid SQL parsing exceptions by using a reserved word as a field name
 	Quote(key string) string
+	// QuoteValue return the value as a SQL literal, used to interpolate vars into statements for `ToSQL`
+	QuoteValue(value interface{}) string
 	// DataTypeOf return data's sql type
 	DataTypeOf(field *StructField) string
 
@@ -27,19 +34,71 @@ type Dialect interface {
 	HasIndex(tableName string, indexName string) bool
 	// HasForeignKey check has foreign key or not
 	HasForeignKey(tableName string, foreignKeyName string) bool
+	// InlineForeignKeys whether foreign key constraints have to be defined in `CREATE TABLE`, sqlite can't add them to existing tables
+	InlineForeignKeys() bool
+	// HasConstraint check has constraint or not
+	HasConstraint(tableName string, constraintName string) bool
+	// CreateConstraintSQL return sql to add constraint to the table, definition is the constraint without its name, e.g. `CHECK (age >= 0)`,
+	// returns `ErrUnsupportedConstraint` if the db can't add constraints to existing tables, e.g. sqlite
+	CreateConstraintSQL(tableName string, constraintName string, definition string) (string, error)
+	// DropConstraintSQL return sql to drop constraint, returns `ErrUnsupportedConstraint` if the db can't drop constraints from existing tables
+	DropConstraintSQL(tableName string, constraintName string) (string, error)
+	// CreateIndexSQL return sql to create the index on the quoted table, returns `ErrUnsupportedIndex` if the dialect can't render an option of the index
+	CreateIndexSQL(tableName string, index Index) (string, error)
 	// RemoveIndex remove index
 	RemoveIndex(tableName string, indexName string) error
+	// RenameIndexSQL return statements to rename index of the table, returns `ErrUnsupportedRename` if the db can't rename the index
+	RenameIndexSQL(tableName string, oldName string, newName string) ([]string, error)
+	// TableNames return names of tables in current database or schema
+	TableNames() ([]string, error)
 	// HasTable check has table or not
 	HasTable(tableName string) bool
 	// HasColumn check has column or not
 	HasColumn(tableName string, columnName string) bool
+	// ColumnTypes return definitions of the table's columns, used by `AutoMigrate` to find changed columns
+	ColumnTypes(tableName string) ([]ColumnType, error)
+	// NormalizeColumnType return the column with its data type replaced by the canonical name, so aliases like `decimal` and `numeric` are the same type when comparing columns
+	NormalizeColumnType(column ColumnType) ColumnType
+	// RenameTableSQL return sql to rename table
+	RenameTableSQL(oldName string, newName string) string
+	// RenameColumnSQL return sql to rename column of the table, its definition and data are kept
+	RenameColumnSQL(tableName string, oldName string, newName string) (string, error)
+	// AlterColumnSQL return statements to change the column from one definition to another, returns `ErrUnsupportedAlterColumn` if the db can't alter columns, e.g. sqlite
+	AlterColumnSQL(tableName string, from, to ColumnType) ([]string, error)
+	// Indexes return indexes of the table except its primary key, used to generate models from the database
+	Indexes(tableName string) ([]TableIndex, error)
+	// ForeignKeys return foreign keys of the table, used to generate models from the database
+	ForeignKeys(tableName string) ([]ForeignKey, error)
+	// InlineComments whether comments are defined in `CREATE TABLE`, e.g. mysql's `COMMENT` column and table options
+	InlineComments() bool
+	// Comments return comments of the table and its columns, columns without comments are omitted
+	Comments(tableName string) (tableComment string, columnComments map[string]string, err error)
+	// TableCommentSQL return sql to set the comment of the table, returns blank if the db doesn't support comments, e.g. sqlite
+	TableCommentSQL(tableName string, comment string) string
+	// ColumnCommentSQL return sql to set the comment of the column, definition is the column's type as `DataTypeOf` returns it, returns blank if the db doesn't support comments
+	ColumnCommentSQL(tableName string, columnName string, definition string, comment string) string
 
 	// LimitAndOffsetSQL return generated SQL with Limit and Offset, as mssql has special case
 	LimitAndOffsetSQL(limit, offset interface{}) string
+	// BuildClause write the clause with the builder if the dialect renders it differently, return false to write it with its own `Build`, e.g. mssql's limit
+	BuildClause(c clause.Interface, builder clause.Builder) bool
+	// LockingSQL return sql to lock rows selected by queries, it is written after tables if tableHint, e.g. mssql's `WITH (UPDLOCK, READPAST)`, otherwise at the end of queries,
+	// sql is blank if the db doesn't lock rows, e.g. sqlite, returns `ErrUnsupportedLocking` if the db doesn't support the strength with the options
+	LockingSQL(strength string, options string) (sql string, tableHint bool, err error)
 	// SelectFromDummyTable return select values, for most dbs, `SELECT values` just works, mysql needs `SELECT value FROM DUAL`
 	SelectFromDummyTable() string
 	// LastInsertIdReturningSuffix most dbs support LastInsertId, but postgres needs to use `RETURNING`
 	LastInsertIDReturningSuffix(tableName, columnName string) string
+	// MaxBindVars return the maximum number of bind vars allowed in one statement, used to split batch inserts, 0 means no limit
+	MaxBindVars() int
+	// BatchFirstInsertID return the id of the first row inserted by a multi-row insert, most dbs' LastInsertId is the id of the last row, but mysql returns the first one
+	BatchFirstInsertID(lastInsertID int64, count int) int64
+	// UpsertSQL return sql to insert values into the table and resolve conflicts with the `OnConflict` option, columns of onConflict are db names, where is its rendered condition
+	UpsertSQL(tableName string, columns []string, values string, onConflict OnConflict, where string) (string, error)
+	// SavePointSQL return sql to create a savepoint inside current transaction
+	SavePointSQL(name string) string
+	// RollbackToSavePointSQL return sql to rollback current transaction to a savepoint
+	RollbackToSavePointSQL(name string) string
 
 	// BuildForeignKeyName returns a foreign key name for the given table, field and reference
 	BuildForeignKeyName(tableName, field, dest string) string
@@ -48,6 +107,85 @@ type Dialect interface {
 	CurrentDatabase() string
 }
 
+// ColumnType definition of a column, returned by `Dialect.ColumnTypes`
+type ColumnType struct {
+	Name string
+	// Type column type without constraints, e.g. `varchar(255)`, `int unsigned`
+	Type string
+	// DataType lower case column type without size, written as `DataTypeOf` would write it, e.g. `varchar`, `int unsigned`
+	DataType string
+	// Length size of string and binary columns, 0 if the type doesn't have a size
+	Length   int
+	Nullable bool
+	// Default default value expression, nil if the column doesn't have one
+	Default    *string
+	PrimaryKey bool
+}
+
+// definition return the column's type with its nullability and default value, e.g. `varchar(64) NOT NULL DEFAULT 'toy'`
+func (column ColumnType) definition() string {
+	definition := column.Type
+	if !column.Nullable {
+		definition += " NOT NULL"
+	}
+	if column.Default != nil {
+		definition += " DEFAULT " + *column.Default
+	}
+	return definition
+}
+
+// SameType check the column has the same data type and size as the other one, sizes are only compared if both columns have one
+func (column ColumnType) SameType(other ColumnType) bool {
+	if column.DataType != other.DataType {
+		return false
+	}
+	return column.Length == 0 || other.Length == 0 || column.Length == other.Length
+}
+
+// SameDefault check the column has the same default value as the other one, quoted and unquoted literals like `'10'` and `10` are the same
+func (column ColumnType) SameDefault(other ColumnType) bool {
+	if column.Default == nil || other.Default == nil {
+		return column.Default == nil && other.Default == nil
+	}
+
+	value, quoted := normalizeColumnDefault(*column.Default)
+	otherValue, otherQuoted := normalizeColumnDefault(*other.Default)
+	if quoted || otherQuoted {
+		return value == otherValue
+	}
+	return strings.EqualFold(value, otherValue)
+}
+
+// TableIndex index of a table, returned by `Dialect.Indexes`
+type TableIndex struct {
+	Name   string
+	Unique bool
+	// Columns names of indexed columns in the order of the index
+	Columns []string
+}
+
+// ForeignKey foreign key of a table, returned by `Dialect.ForeignKeys`
+type ForeignKey struct {
+	// Name name of the constraint, sqlite doesn't keep names of foreign keys, they are named `fk_<id>`
+	Name       string
+	Columns    []string
+	RefTable   string
+	RefColumns []string
+}
+
+// normalizeColumnDefault remove parentheses and quotes around the default value, returns whether it was quoted
+func normalizeColumnDefault(value string) (string, bool) {
+	value = strings.TrimSpace(value)
+	for len(value) > 1 && strings.HasPrefix(value, "(") && strings.HasSuffix(value, ")") {
+		value = strings.TrimSpace(value[1 : len(value)-1])
+	}
+
+	if len(value) > 1 && strings.HasPrefix(value, "'") && strings.HasSuffix(value, "'") {
+		return strings.Replace(value[1:len(value)-1], "''", "'", -1), true
+	}
+	return value, false
+}
+
 var dialectsMap = map[string]Dialect{}
 
 func newDialect(name string, db SQLCommon) Dialect {
@@ -114,3 +252,118 @@ var ParseFieldStructForDialect = func(field *StructField, dialect Dialect) (fiel
 
 	return fieldValue, dataType, size, strings.TrimSpace(additionalType)
 }
+
+var columnTypeArgsRegexp = regexp.MustCompile(`\s*\(([^)]*)\)`)
+
+// parseColumnType split a column type into its lower case data type without arguments and its size, e.g. `varchar(255)` into `varchar` and 255,
+// the size is 0 if the type doesn't have one, or has several arguments like `decimal(10,2)`
+func parseColumnType(typ string) (dataType string, length int) {
+	if matches := columnTypeArgsRegexp.FindStringSubmatch(typ); len(matches) > 0 {
+		length, _ = strconv.Atoi(strings.TrimSpace(matches[1]))
+	}
+	return strings.ToLower(strings.TrimSpace(columnTypeArgsRegexp.ReplaceAllString(typ, ""))), length
+}
+
+// parseColumnConstraints parse nullability and default from constraints written after a column's type, e.g. `NOT NULL DEFAULT 'a b'` of tag `type:varchar(100) NOT NULL DEFAULT 'a b'`,
+// quoted and parenthesized values are kept as a whole
+func parseColumnConstraints(constraints string) (notNull bool, defaultValue *string) {
+	var (
+		tokens []string
+		start  = -1
+		depth  int
+		quote  rune
+	)
+	for idx, r := range constraints {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case r == '(':
+			depth++
+		case r == ')':
+			depth--
+		case r == ' ' && depth == 0:
+			if start >= 0 {
+				tokens = append(tokens, constraints[start:idx])
+				start = -1
+			}
+			continue
+		}
+
+		if start < 0 {
+			start = idx
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, constraints[start:])
+	}
+
+	for idx := 0; idx < len(tokens)-1; idx++ {
+		switch strings.ToUpper(tokens[idx]) {
+		case "NOT":
+			notNull = notNull || strings.ToUpper(tokens[idx+1]) == "NULL"
+		case "DEFAULT":
+			value := tokens[idx+1]
+			defaultValue = &value
+		}
+	}
+	return
+}
+
+// normalizeDataType replace the column's data type with its canonical name if it is an alias
+func normalizeDataType(column ColumnType, aliases map[string]string) ColumnType {
+	if dataType, ok := aliases[column.DataType]; ok {
+		column.DataType = dataType
+	}
+	return column
+}
+
+// ParseValueForDialect get the value that would be sent to the database, pointers are dereferenced and `driver.Valuer`s are evaluated,
+// returns nil, bool, int64, uint64, float64, string, []byte, time.Time, or the value itself if it isn't one of them
+func ParseValueForDialect(value interface{}) interface{} {
+	for {
+		reflectValue := reflect.ValueOf(value)
+		if !reflectValue.IsValid() || (reflectValue.Kind() == reflect.Ptr && reflectValue.IsNil()) {
+			return nil
+		}
+
+		if valuer, ok := value.(driver.Valuer); ok {
+			if v, err := valuer.Value(); err == nil {
+				value = v
+				break
+			}
+		}
+
+		if reflectValue.Kind() != reflect.Ptr {
+			break
+		}
+		value = reflectValue.Elem().Interface()
+	}
+
+	switch value.(type) {
+	case nil, bool, int64, uint64, float64, string, []byte, time.Time:
+		return value
+	}
+
+	reflectValue := reflect.ValueOf(value)
+	switch reflectValue.Kind() {
+	case reflect.Bool:
+		return reflectValue.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return reflectValue.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return reflectValue.Uint()
+	case reflect.Float32, reflect.Float64:
+		return reflectValue.Float()
+	case reflect.String:
+		return reflectValue.String()
+	case reflect.Slice:
+		if reflectValue.Type().Elem().Kind() == reflect.Uint8 {
+			return reflectValue.Bytes()
+		}
+	}
+	return value
+}
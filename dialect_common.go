@@ -1,12 +1,15 @@
 package gorm
 
 import (
+	"database/sql"
 	"fmt"
 	"reflect"
 	"regexp"
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/jinzhu/gorm/clause"
 )
 
 // DefaultForeignKeyNamer contains the default foreign key name generator method
@@ -38,6 +41,28 @@ func (commonDialect) Quote(key string) string {
 	return fmt.Sprintf(`"%s"`, key)
 }
 
+func (commonDialect) QuoteValue(value interface{}) string {
+	switch v := ParseValueForDialect(value).(type) {
+	case nil:
+		return "NULL"
+	case bool:
+		if v {
+			return "TRUE"
+		}
+		return "FALSE"
+	case int64, uint64:
+		return fmt.Sprint(v)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case []byte:
+		return fmt.Sprintf("X'%x'", v)
+	case time.Time:
+		return fmt.Sprintf("'%v'", v.Format("2006-01-02 15:04:05.999999999-07:00"))
+	default:
+		return "'" + strings.Replace(fmt.Sprint(v), "'", "''", -1) + "'"
+	}
+}
+
 func (s *commonDialect) DataTypeOf(field *StructField) string {
 	var dataValue, sqlType, size, additionalType = ParseFieldStructForDialect(field, s)
 
@@ -96,15 +121,56 @@ func (s commonDialect) HasIndex(tableName string, indexName string) bool {
 	return count > 0
 }
 
+func (commonDialect) CreateIndexSQL(tableName string, index Index) (string, error) {
+	if index.Type != "" {
+		return "", ErrUnsupportedIndex
+	}
+
+	sqlCreate := "CREATE INDEX"
+	if index.Unique {
+		sqlCreate = "CREATE UNIQUE INDEX"
+	}
+
+	sql := fmt.Sprintf("%v %v ON %v(%v)", sqlCreate, index.Name, tableName, indexColumnsSQL(index))
+	if index.Where != "" {
+		sql += " WHERE " + index.Where
+	}
+	return sql, nil
+}
+
 func (s commonDialect) RemoveIndex(tableName string, indexName string) error {
 	_, err := s.db.Exec(fmt.Sprintf("DROP INDEX %v", indexName))
 	return err
 }
 
+func (s commonDialect) RenameIndexSQL(tableName string, oldName string, newName string) ([]string, error) {
+	return []string{fmt.Sprintf("ALTER INDEX %v RENAME TO %v", oldName, newName)}, nil
+}
+
 func (s commonDialect) HasForeignKey(tableName string, foreignKeyName string) bool {
+	var count int
+	s.db.QueryRow("SELECT count(*) FROM INFORMATION_SCHEMA.TABLE_CONSTRAINTS WHERE table_schema = ? AND table_name = ? AND constraint_name = ? AND constraint_type = 'FOREIGN KEY'", s.CurrentDatabase(), tableName, foreignKeyName).Scan(&count)
+	return count > 0
+}
+
+func (commonDialect) InlineForeignKeys() bool {
 	return false
 }
 
+func (s commonDialect) HasConstraint(tableName string, constraintName string) bool {
+	var count int
+	s.db.QueryRow("SELECT count(*) FROM INFORMATION_SCHEMA.TABLE_CONSTRAINTS WHERE table_schema = ? AND table_name = ? AND constraint_name = ?", s.CurrentDatabase(), tableName, constraintName).Scan(&count)
+	return count > 0
+}
+
+func (s commonDialect) CreateConstraintSQL(tableName string, constraintName string, definition string) (string, error) {
+	return fmt.Sprintf("ALTER TABLE %v ADD CONSTRAINT %v %v", s.Quote(tableName), s.Quote(constraintName), definition), nil
+}
+
+func (s commonDialect) DropConstraintSQL(tableName string, constraintName string) (string, error) {
+	return fmt.Sprintf("ALTER TABLE %v DROP CONSTRAINT %v", s.Quote(tableName), s.Quote(constraintName)), nil
+}
+
 func (s commonDialect) HasTable(tableName string) bool {
 	var count int
 	s.db.QueryRow("SELECT count(*) FROM INFORMATION_SCHEMA.TABLES WHERE table_schema = ? AND table_name = ?", s.CurrentDatabase(), tableName).Scan(&count)
@@ -117,11 +183,125 @@ func (s commonDialect) HasColumn(tableName string, columnName string) bool {
 	return count > 0
 }
 
+func (s commonDialect) ColumnTypes(tableName string) ([]ColumnType, error) {
+	return queryColumnTypes(s.db, `SELECT c.column_name, c.data_type, c.character_maximum_length, c.is_nullable, c.column_default,
+	(SELECT count(*) FROM INFORMATION_SCHEMA.TABLE_CONSTRAINTS tc JOIN INFORMATION_SCHEMA.KEY_COLUMN_USAGE kcu ON tc.constraint_name = kcu.constraint_name AND tc.table_schema = kcu.table_schema AND tc.table_name = kcu.table_name
+	WHERE tc.constraint_type = 'PRIMARY KEY' AND kcu.table_schema = c.table_schema AND kcu.table_name = c.table_name AND kcu.column_name = c.column_name)
+	FROM INFORMATION_SCHEMA.COLUMNS c WHERE c.table_schema = ? AND c.table_name = ? ORDER BY c.ordinal_position`, s.CurrentDatabase(), tableName)
+}
+
+func (commonDialect) NormalizeColumnType(column ColumnType) ColumnType {
+	return column
+}
+
+func (s commonDialect) RenameTableSQL(oldName string, newName string) string {
+	return fmt.Sprintf("ALTER TABLE %v RENAME TO %v", s.Quote(oldName), s.Quote(newName))
+}
+
+func (s commonDialect) RenameColumnSQL(tableName string, oldName string, newName string) (string, error) {
+	return fmt.Sprintf("ALTER TABLE %v RENAME COLUMN %v TO %v", s.Quote(tableName), s.Quote(oldName), s.Quote(newName)), nil
+}
+
+func (s commonDialect) AlterColumnSQL(tableName string, from, to ColumnType) ([]string, error) {
+	var clauses []string
+	column := s.Quote(to.Name)
+
+	if !from.SameType(to) {
+		clauses = append(clauses, fmt.Sprintf("ALTER COLUMN %v TYPE %v", column, to.Type))
+	}
+
+	if from.Nullable != to.Nullable {
+		if to.Nullable {
+			clauses = append(clauses, fmt.Sprintf("ALTER COLUMN %v DROP NOT NULL", column))
+		} else {
+			clauses = append(clauses, fmt.Sprintf("ALTER COLUMN %v SET NOT NULL", column))
+		}
+	}
+
+	if !from.SameDefault(to) {
+		if to.Default == nil {
+			clauses = append(clauses, fmt.Sprintf("ALTER COLUMN %v DROP DEFAULT", column))
+		} else {
+			clauses = append(clauses, fmt.Sprintf("ALTER COLUMN %v SET DEFAULT %v", column, *to.Default))
+		}
+	}
+
+	if len(clauses) == 0 {
+		return nil, nil
+	}
+	return []string{fmt.Sprintf("ALTER TABLE %v %v", s.Quote(tableName), strings.Join(clauses, ", "))}, nil
+}
+
+func (s commonDialect) TableNames() ([]string, error) {
+	return queryTableNames(s.db, "SELECT table_name FROM INFORMATION_SCHEMA.TABLES WHERE table_schema = ? AND table_type = 'BASE TABLE' ORDER BY table_name", s.CurrentDatabase())
+}
+
+func (s commonDialect) Indexes(tableName string) ([]TableIndex, error) {
+	return queryIndexes(s.db, "SELECT index_name, non_unique = 0, column_name FROM INFORMATION_SCHEMA.STATISTICS WHERE table_schema = ? AND table_name = ? AND index_name <> 'PRIMARY' ORDER BY index_name, seq_in_index", s.CurrentDatabase(), tableName)
+}
+
+func (s commonDialect) ForeignKeys(tableName string) ([]ForeignKey, error) {
+	return queryForeignKeys(s.db, `SELECT constraint_name, column_name, referenced_table_name, referenced_column_name FROM INFORMATION_SCHEMA.KEY_COLUMN_USAGE
+	WHERE table_schema = ? AND table_name = ? AND referenced_table_name IS NOT NULL ORDER BY constraint_name, ordinal_position`, s.CurrentDatabase(), tableName)
+}
+
+func (commonDialect) InlineComments() bool {
+	return false
+}
+
+func (commonDialect) Comments(tableName string) (string, map[string]string, error) {
+	return "", nil, nil
+}
+
+func (commonDialect) TableCommentSQL(tableName string, comment string) string {
+	return ""
+}
+
+func (commonDialect) ColumnCommentSQL(tableName string, columnName string, definition string, comment string) string {
+	return ""
+}
+
+// queryColumnComments query names and comments of columns, columns without comments are omitted
+func queryColumnComments(db SQLCommon, query string, args ...interface{}) (map[string]string, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	comments := map[string]string{}
+	for rows.Next() {
+		var (
+			name    string
+			comment sql.NullString
+		)
+		if err := rows.Scan(&name, &comment); err != nil {
+			return nil, err
+		}
+		if comment.String != "" {
+			comments[name] = comment.String
+		}
+	}
+	return comments, rows.Err()
+}
+
 func (s commonDialect) CurrentDatabase() (name string) {
 	s.db.QueryRow("SELECT DATABASE()").Scan(&name)
 	return
 }
 
+func (commonDialect) BuildClause(c clause.Interface, builder clause.Builder) bool {
+	return false
+}
+
+func (commonDialect) LockingSQL(strength string, options string) (string, bool, error) {
+	sql := "FOR " + strength
+	if options != "" {
+		sql += " " + options
+	}
+	return sql, false, nil
+}
+
 func (commonDialect) LimitAndOffsetSQL(limit, offset interface{}) (sql string) {
 	if limit != nil {
 		if parsedLimit, err := strconv.ParseInt(fmt.Sprint(limit), 0, 0); err == nil && parsedLimit > 0 {
@@ -144,6 +324,161 @@ func (commonDialect) LastInsertIDReturningSuffix(tableName, columnName string) s
 	return ""
 }
 
+func (commonDialect) MaxBindVars() int {
+	return 999
+}
+
+func (commonDialect) BatchFirstInsertID(lastInsertID int64, count int) int64 {
+	return lastInsertID - int64(count) + 1
+}
+
+func (s commonDialect) UpsertSQL(tableName string, columns []string, values string, onConflict OnConflict, where string) (string, error) {
+	sql := fmt.Sprintf("INSERT INTO %v (%v) VALUES %v ON CONFLICT", tableName, strings.Join(columns, ","), values)
+
+	if len(onConflict.Columns) > 0 {
+		var conflictColumns []string
+		for _, column := range onConflict.Columns {
+			conflictColumns = append(conflictColumns, s.Quote(column))
+		}
+		sql += fmt.Sprintf(" (%v)", strings.Join(conflictColumns, ","))
+	}
+
+	if onConflict.DoNothing {
+		return sql + " DO NOTHING", nil
+	}
+
+	var sets []string
+	for _, column := range onConflict.UpdateColumns {
+		sets = append(sets, fmt.Sprintf("%v = excluded.%v", s.Quote(column), s.Quote(column)))
+	}
+	sql += " DO UPDATE SET " + strings.Join(sets, ", ")
+
+	if where != "" {
+		sql += " WHERE " + where
+	}
+	return sql, nil
+}
+
+func (commonDialect) SavePointSQL(name string) string {
+	return fmt.Sprintf("SAVEPOINT %v", name)
+}
+
+func (commonDialect) RollbackToSavePointSQL(name string) string {
+	return fmt.Sprintf("ROLLBACK TO SAVEPOINT %v", name)
+}
+
+// queryColumnTypes query definitions of columns, the query should select name, type, max length, nullable (`YES` or `NO`),
+// default value and whether the column is a primary key (count of primary key constraints) of each column
+func queryColumnTypes(db SQLCommon, query string, args ...interface{}) ([]ColumnType, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columnTypes []ColumnType
+	for rows.Next() {
+		var (
+			columnType   ColumnType
+			length       sql.NullInt64
+			nullable     string
+			defaultValue sql.NullString
+			primaryKeys  int
+		)
+
+		if err := rows.Scan(&columnType.Name, &columnType.Type, &length, &nullable, &defaultValue, &primaryKeys); err != nil {
+			return nil, err
+		}
+
+		columnType.DataType, _ = parseColumnType(columnType.Type)
+		if length.Valid && length.Int64 > 0 {
+			columnType.Length = int(length.Int64)
+			if !strings.Contains(columnType.Type, "(") {
+				columnType.Type = fmt.Sprintf("%v(%d)", columnType.Type, columnType.Length)
+			}
+		}
+		columnType.Nullable = strings.EqualFold(nullable, "YES")
+		if defaultValue.Valid {
+			columnType.Default = &defaultValue.String
+		}
+		columnType.PrimaryKey = primaryKeys > 0
+		columnTypes = append(columnTypes, columnType)
+	}
+	return columnTypes, rows.Err()
+}
+
+// queryTableNames query names of tables
+func queryTableNames(db SQLCommon, query string, args ...interface{}) ([]string, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tableNames []string
+	for rows.Next() {
+		var tableName string
+		if err := rows.Scan(&tableName); err != nil {
+			return nil, err
+		}
+		tableNames = append(tableNames, tableName)
+	}
+	return tableNames, rows.Err()
+}
+
+// queryIndexes query index name, whether it is unique and column name for each indexed column, rows of the same index should be adjacent and ordered as the index
+func queryIndexes(db SQLCommon, query string, args ...interface{}) ([]TableIndex, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var indexes []TableIndex
+	for rows.Next() {
+		var index TableIndex
+		var column string
+		if err := rows.Scan(&index.Name, &index.Unique, &column); err != nil {
+			return nil, err
+		}
+
+		if last := len(indexes) - 1; last >= 0 && indexes[last].Name == index.Name {
+			indexes[last].Columns = append(indexes[last].Columns, column)
+		} else {
+			index.Columns = []string{column}
+			indexes = append(indexes, index)
+		}
+	}
+	return indexes, rows.Err()
+}
+
+// queryForeignKeys query constraint name, column name, referenced table and referenced column for each column of foreign keys, rows of the same foreign key should be adjacent and ordered
+func queryForeignKeys(db SQLCommon, query string, args ...interface{}) ([]ForeignKey, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var foreignKeys []ForeignKey
+	for rows.Next() {
+		var foreignKey ForeignKey
+		var column, refColumn string
+		if err := rows.Scan(&foreignKey.Name, &column, &foreignKey.RefTable, &refColumn); err != nil {
+			return nil, err
+		}
+
+		if last := len(foreignKeys) - 1; last >= 0 && foreignKeys[last].Name == foreignKey.Name {
+			foreignKeys[last].Columns = append(foreignKeys[last].Columns, column)
+			foreignKeys[last].RefColumns = append(foreignKeys[last].RefColumns, refColumn)
+		} else {
+			foreignKey.Columns, foreignKey.RefColumns = []string{column}, []string{refColumn}
+			foreignKeys = append(foreignKeys, foreignKey)
+		}
+	}
+	return foreignKeys, rows.Err()
+}
+
 func (DefaultForeignKeyNamer) BuildForeignKeyName(tableName, field, dest string) string {
 	keyName := fmt.Sprintf("%s_%s_%s_foreign", tableName, field, dest)
 	keyName = regexp.MustCompile("(_*[^a-zA-Z]+_*|_+)").ReplaceAllString(keyName, "_")
@@ -2,16 +2,22 @@ package gorm
 
 import (
 	"crypto/sha1"
+	"database/sql"
 	"fmt"
 	"reflect"
 	"regexp"
 	"strings"
 	"time"
 	"unicode/utf8"
+
+	"github.com/jinzhu/gorm/clause"
 )
 
 type mysql struct {
 	commonDialect
+	// version the server's version detected when setting the connection, mariadb versions are reported for mariadb servers
+	version [3]int
+	mariadb bool
 }
 
 func init() {
@@ -26,6 +32,18 @@ func (mysql) Quote(key string) string {
 	return fmt.Sprintf("`%s`", key)
 }
 
+// QuoteValue mysql treats backslashes in strings as escape characters, and its datetime doesn't have time zone
+func (s mysql) QuoteValue(value interface{}) string {
+	switch v := ParseValueForDialect(value).(type) {
+	case string:
+		return "'" + strings.NewReplacer(`\`, `\\`, "'", "''").Replace(v) + "'"
+	case time.Time:
+		return fmt.Sprintf("'%v'", v.Format("2006-01-02 15:04:05.999999"))
+	default:
+		return s.commonDialect.QuoteValue(v)
+	}
+}
+
 // Get Data Type for MySQL Dialect
 func (s *mysql) DataTypeOf(field *StructField) string {
 	var dataValue, sqlType, size, additionalType = ParseFieldStructForDialect(field, s)
@@ -107,17 +125,254 @@ func (s *mysql) DataTypeOf(field *StructField) string {
 	return fmt.Sprintf("%v %v", sqlType, additionalType)
 }
 
+// CreateIndexSQL mysql doesn't support partial indexes, `fulltext` and `spatial` types are index classes, `btree` and `hash` are index methods
+func (mysql) CreateIndexSQL(tableName string, index Index) (string, error) {
+	if index.Where != "" {
+		return "", ErrUnsupportedIndex
+	}
+
+	sqlCreate := "CREATE INDEX"
+	if index.Unique {
+		sqlCreate = "CREATE UNIQUE INDEX"
+	}
+
+	var using string
+	switch typ := strings.ToUpper(index.Type); typ {
+	case "":
+	case "FULLTEXT", "SPATIAL":
+		if index.Unique {
+			return "", ErrUnsupportedIndex
+		}
+		sqlCreate = fmt.Sprintf("CREATE %v INDEX", typ)
+	case "BTREE", "HASH":
+		using = " USING " + typ
+	default:
+		return "", ErrUnsupportedIndex
+	}
+
+	return fmt.Sprintf("%v %v%v ON %v(%v)", sqlCreate, index.Name, using, tableName, indexColumnsSQL(index)), nil
+}
+
 func (s mysql) RemoveIndex(tableName string, indexName string) error {
 	_, err := s.db.Exec(fmt.Sprintf("DROP INDEX %v ON %v", indexName, s.Quote(tableName)))
 	return err
 }
 
+// RenameIndexSQL `RENAME INDEX` is supported since mysql 5.7 and mariadb 10.5.2
+func (s mysql) RenameIndexSQL(tableName string, oldName string, newName string) ([]string, error) {
+	return []string{fmt.Sprintf("ALTER TABLE %v RENAME INDEX %v TO %v", s.Quote(tableName), oldName, newName)}, nil
+}
+
 func (s mysql) HasForeignKey(tableName string, foreignKeyName string) bool {
 	var count int
 	s.db.QueryRow("SELECT count(*) FROM INFORMATION_SCHEMA.TABLE_CONSTRAINTS WHERE CONSTRAINT_SCHEMA=? AND TABLE_NAME=? AND CONSTRAINT_NAME=? AND CONSTRAINT_TYPE='FOREIGN KEY'", s.CurrentDatabase(), tableName, foreignKeyName).Scan(&count)
 	return count > 0
 }
 
+func (s mysql) ColumnTypes(tableName string) ([]ColumnType, error) {
+	columnTypes, err := queryColumnTypes(s.db, "SELECT COLUMN_NAME, COLUMN_TYPE, CHARACTER_MAXIMUM_LENGTH, IS_NULLABLE, COLUMN_DEFAULT, COLUMN_KEY = 'PRI' FROM INFORMATION_SCHEMA.COLUMNS WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? ORDER BY ORDINAL_POSITION", s.CurrentDatabase(), tableName)
+
+	for idx, columnType := range columnTypes {
+		// `boolean` is an alias of `tinyint(1)`
+		if strings.ToLower(columnType.Type) == "tinyint(1)" {
+			columnType.DataType = "boolean"
+		}
+
+		// mariadb returns `NULL` for columns without default value
+		if columnType.Default != nil && strings.ToUpper(*columnType.Default) == "NULL" {
+			columnType.Default = nil
+		}
+		columnTypes[idx] = columnType
+	}
+	return columnTypes, err
+}
+
+// mysqlDataTypeAliases aliases of types written in tags, mysql reports their canonical names
+var mysqlDataTypeAliases = map[string]string{
+	"integer":           "int",
+	"bool":              "boolean",
+	"dec":               "decimal",
+	"numeric":           "decimal",
+	"fixed":             "decimal",
+	"real":              "double",
+	"double precision":  "double",
+	"character varying": "varchar",
+	"character":         "char",
+}
+
+// NormalizeColumnType `boolean` is an alias of `tinyint(1)`
+func (mysql) NormalizeColumnType(column ColumnType) ColumnType {
+	if column.DataType == "tinyint" && (column.Length == 1 || strings.ToLower(column.Type) == "tinyint(1)") {
+		column.DataType, column.Length = "boolean", 0
+	}
+	return normalizeDataType(column, mysqlDataTypeAliases)
+}
+
+func (s mysql) AlterColumnSQL(tableName string, from, to ColumnType) ([]string, error) {
+	sql := fmt.Sprintf("ALTER TABLE %v MODIFY %v %v", s.Quote(tableName), s.Quote(to.Name), to.Type)
+	if to.Nullable {
+		sql += " NULL"
+	} else {
+		sql += " NOT NULL"
+	}
+
+	if to.Default != nil {
+		sql += " DEFAULT " + *to.Default
+	}
+
+	options, err := s.columnOptions(tableName, from.Name)
+	if options != "" {
+		sql += " " + options
+	}
+	return []string{sql}, err
+}
+
+// RenameColumnSQL `RENAME COLUMN` is supported since mysql 8.0 and mariadb 10.5.2, older versions use `CHANGE` with the column's current definition
+func (s mysql) RenameColumnSQL(tableName string, oldName string, newName string) (string, error) {
+	if s.serverVersionAtLeast(8, 0, 0, 10, 5, 2) {
+		return fmt.Sprintf("ALTER TABLE %v RENAME COLUMN %v TO %v", s.Quote(tableName), s.Quote(oldName), s.Quote(newName)), nil
+	}
+
+	var (
+		columnType, nullable string
+		defaultValue         *string
+	)
+	if err := s.db.QueryRow("SELECT COLUMN_TYPE, IS_NULLABLE, COLUMN_DEFAULT FROM INFORMATION_SCHEMA.COLUMNS WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? AND COLUMN_NAME = ?", s.CurrentDatabase(), tableName, oldName).Scan(&columnType, &nullable, &defaultValue); err != nil {
+		return "", err
+	}
+
+	definition := columnType
+	if nullable == "NO" {
+		definition += " NOT NULL"
+	}
+	if defaultValue != nil {
+		// mysql returns literal defaults without quotes
+		if strings.HasPrefix(strings.ToUpper(*defaultValue), "CURRENT_TIMESTAMP") {
+			definition += " DEFAULT " + *defaultValue
+		} else {
+			definition += " DEFAULT " + s.QuoteValue(*defaultValue)
+		}
+	}
+
+	options, err := s.columnOptions(tableName, oldName)
+	if options != "" {
+		definition += " " + options
+	}
+	return fmt.Sprintf("ALTER TABLE %v CHANGE %v %v %v", s.Quote(tableName), s.Quote(oldName), s.Quote(newName), definition), err
+}
+
+// columnOptions return options of the column `MODIFY` and `CHANGE` would drop if they aren't written again, e.g. `AUTO_INCREMENT`, `ON UPDATE CURRENT_TIMESTAMP` and `COMMENT`
+func (s mysql) columnOptions(tableName string, columnName string) (string, error) {
+	var extra, comment string
+	if err := s.db.QueryRow("SELECT EXTRA, COLUMN_COMMENT FROM INFORMATION_SCHEMA.COLUMNS WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? AND COLUMN_NAME = ?", s.CurrentDatabase(), tableName, columnName).Scan(&extra, &comment); err != nil {
+		return "", err
+	}
+
+	// mysql 8 marks columns whose defaults are expressions with `DEFAULT_GENERATED`, it isn't an option
+	var options []string
+	if extra = strings.TrimSpace(strings.Replace(extra, "DEFAULT_GENERATED", "", 1)); extra != "" {
+		options = append(options, extra)
+	}
+	if comment != "" {
+		options = append(options, "COMMENT "+s.QuoteValue(comment))
+	}
+	return strings.Join(options, " "), nil
+}
+
+// serverVersionAtLeast check the server's version is at least the mysql version, or the mariadb version for mariadb servers
+func (s mysql) serverVersionAtLeast(major, minor, patch, mariadbMajor, mariadbMinor, mariadbPatch int) bool {
+	current, mariadb := s.serverVersion()
+	if mariadb {
+		major, minor, patch = mariadbMajor, mariadbMinor, mariadbPatch
+	}
+
+	for idx, required := range []int{major, minor, patch} {
+		if current[idx] != required {
+			return current[idx] > required
+		}
+	}
+	return true
+}
+
+// SetDB set the connection and detect the server's version once, so statements depending on it are rendered without querying the server
+func (s *mysql) SetDB(db SQLCommon) {
+	s.commonDialect.SetDB(db)
+	// the connection isn't available if `Open` failed to open it
+	if sqlDB, ok := db.(*sql.DB); db == nil || (ok && sqlDB == nil) {
+		return
+	}
+
+	var version string
+	if err := db.QueryRow("SELECT VERSION()").Scan(&version); err == nil {
+		fmt.Sscanf(version, "%d.%d.%d", &s.version[0], &s.version[1], &s.version[2])
+		s.mariadb = strings.Contains(strings.ToLower(version), "mariadb")
+	}
+}
+
+// serverVersion return the server's version and whether it is a mariadb server
+func (s mysql) serverVersion() (current [3]int, mariadb bool) {
+	return s.version, s.mariadb
+}
+
+// LockingSQL mysql supports `FOR SHARE`, `NOWAIT` and `SKIP LOCKED` since 8.0, mariadb and older servers lock rows in share mode with `LOCK IN SHARE MODE`,
+// mariadb supports both options since 10.6
+func (s mysql) LockingSQL(strength string, options string) (string, bool, error) {
+	sql := "FOR " + strength
+	if current, mariadb := s.serverVersion(); mariadb || current[0] < 8 {
+		if strength == clause.LockingStrengthShare {
+			sql = "LOCK IN SHARE MODE"
+		}
+
+		if options != "" && (!mariadb || current[0] < 10 || (current[0] == 10 && current[1] < 6)) {
+			return "", false, ErrUnsupportedLocking
+		}
+	}
+
+	if options != "" {
+		sql += " " + options
+	}
+	return sql, false, nil
+}
+
+// InlineComments mysql defines comments with `COMMENT` column and table options
+func (mysql) InlineComments() bool {
+	return true
+}
+
+func (s mysql) Comments(tableName string) (string, map[string]string, error) {
+	var tableComment string
+	if err := s.db.QueryRow("SELECT TABLE_COMMENT FROM INFORMATION_SCHEMA.TABLES WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ?", s.CurrentDatabase(), tableName).Scan(&tableComment); err != nil {
+		return "", nil, err
+	}
+
+	columnComments, err := queryColumnComments(s.db, "SELECT COLUMN_NAME, COLUMN_COMMENT FROM INFORMATION_SCHEMA.COLUMNS WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ?", s.CurrentDatabase(), tableName)
+	return tableComment, columnComments, err
+}
+
+func (s mysql) TableCommentSQL(tableName string, comment string) string {
+	return fmt.Sprintf("ALTER TABLE %v COMMENT = %v", s.Quote(tableName), s.QuoteValue(comment))
+}
+
+// ColumnCommentSQL mysql can only change the comment by redefining the column
+func (s mysql) ColumnCommentSQL(tableName string, columnName string, definition string, comment string) string {
+	return fmt.Sprintf("ALTER TABLE %v MODIFY %v %v COMMENT %v", s.Quote(tableName), s.Quote(columnName), definition, s.QuoteValue(comment))
+}
+
+// DropConstraintSQL mysql drops constraints by their types, `DROP CONSTRAINT` is only supported since 8.0.19
+func (s mysql) DropConstraintSQL(tableName string, constraintName string) (string, error) {
+	var constraintType string
+	s.db.QueryRow("SELECT CONSTRAINT_TYPE FROM INFORMATION_SCHEMA.TABLE_CONSTRAINTS WHERE CONSTRAINT_SCHEMA = ? AND TABLE_NAME = ? AND CONSTRAINT_NAME = ?", s.CurrentDatabase(), tableName, constraintName).Scan(&constraintType)
+
+	drop := "CHECK"
+	switch constraintType {
+	case "FOREIGN KEY":
+		drop = "FOREIGN KEY"
+	case "UNIQUE":
+		drop = "INDEX"
+	}
+	return fmt.Sprintf("ALTER TABLE %v DROP %v %v", s.Quote(tableName), drop, s.Quote(constraintName)), nil
+}
+
 func (s mysql) CurrentDatabase() (name string) {
 	s.db.QueryRow("SELECT DATABASE()").Scan(&name)
 	return
@@ -127,6 +382,37 @@ func (mysql) SelectFromDummyTable() string {
 	return "FROM DUAL"
 }
 
+func (mysql) MaxBindVars() int {
+	return 65535
+}
+
+func (mysql) BatchFirstInsertID(lastInsertID int64, count int) int64 {
+	return lastInsertID
+}
+
+func (s mysql) UpsertSQL(tableName string, columns []string, values string, onConflict OnConflict, where string) (string, error) {
+	if where != "" {
+		return "", ErrUnsupportedOnConflict
+	}
+
+	sql := fmt.Sprintf("INSERT INTO %v (%v) VALUES %v ON DUPLICATE KEY UPDATE", tableName, strings.Join(columns, ","), values)
+
+	if onConflict.DoNothing {
+		// set a column to itself to skip conflicted records, `INSERT IGNORE` would ignore other errors too
+		column := columns[0]
+		if len(onConflict.Columns) > 0 {
+			column = s.Quote(onConflict.Columns[0])
+		}
+		return fmt.Sprintf("%v %v = %v", sql, column, column), nil
+	}
+
+	var sets []string
+	for _, column := range onConflict.UpdateColumns {
+		sets = append(sets, fmt.Sprintf("%v = VALUES(%v)", s.Quote(column), s.Quote(column)))
+	}
+	return sql + " " + strings.Join(sets, ", "), nil
+}
+
 func (s mysql) BuildForeignKeyName(tableName, field, dest string) string {
 	keyName := s.commonDialect.BuildForeignKeyName(tableName, field, dest)
 	if utf8.RuneCountInString(keyName) <= 64 {
@@ -1,6 +1,7 @@
 package gorm
 
 import (
+	"database/sql"
 	"fmt"
 	"reflect"
 	"strings"
@@ -23,6 +24,13 @@ func (postgres) BindVar(i int) string {
 	return fmt.Sprintf("$%v", i)
 }
 
+func (s postgres) QuoteValue(value interface{}) string {
+	if v, ok := ParseValueForDialect(value).([]byte); ok {
+		return fmt.Sprintf(`'\x%x'`, v)
+	}
+	return s.commonDialect.QuoteValue(value)
+}
+
 func (s *postgres) DataTypeOf(field *StructField) string {
 	var dataValue, sqlType, size, additionalType = ParseFieldStructForDialect(field, s)
 
@@ -83,6 +91,28 @@ func (s *postgres) DataTypeOf(field *StructField) string {
 	return fmt.Sprintf("%v %v", sqlType, additionalType)
 }
 
+func (postgres) CreateIndexSQL(tableName string, index Index) (string, error) {
+	sqlCreate := "CREATE INDEX"
+	if index.Unique {
+		sqlCreate = "CREATE UNIQUE INDEX"
+	}
+
+	var using string
+	switch strings.ToLower(index.Type) {
+	case "":
+	case "fulltext", "spatial":
+		return "", ErrUnsupportedIndex
+	default:
+		using = " USING " + index.Type
+	}
+
+	sql := fmt.Sprintf("%v %v ON %v%v(%v)", sqlCreate, index.Name, tableName, using, indexColumnsSQL(index))
+	if index.Where != "" {
+		sql += " WHERE " + index.Where
+	}
+	return sql, nil
+}
+
 func (s postgres) HasIndex(tableName string, indexName string) bool {
 	var count int
 	s.db.QueryRow("SELECT count(*) FROM pg_indexes WHERE tablename = $1 AND indexname = $2", tableName, indexName).Scan(&count)
@@ -95,6 +125,34 @@ func (s postgres) HasForeignKey(tableName string, foreignKeyName string) bool {
 	return count > 0
 }
 
+func (s postgres) HasConstraint(tableName string, constraintName string) bool {
+	var count int
+	s.db.QueryRow("SELECT count(con.conname) FROM pg_constraint con WHERE $1::regclass::oid = con.conrelid AND con.conname = $2", tableName, constraintName).Scan(&count)
+	return count > 0
+}
+
+func (s postgres) TableNames() ([]string, error) {
+	return queryTableNames(s.db, "SELECT table_name FROM INFORMATION_SCHEMA.tables WHERE table_schema = CURRENT_SCHEMA() AND table_type = 'BASE TABLE' ORDER BY table_name")
+}
+
+func (s postgres) Indexes(tableName string) ([]TableIndex, error) {
+	return queryIndexes(s.db, `SELECT c.relname, i.indisunique, a.attname FROM pg_index i
+	JOIN pg_class c ON c.oid = i.indexrelid
+	JOIN pg_attribute a ON a.attrelid = i.indrelid AND a.attnum = ANY(i.indkey)
+	WHERE i.indrelid = quote_ident($1)::regclass AND NOT i.indisprimary
+	ORDER BY c.relname, array_position(i.indkey::int2[], a.attnum)`, tableName)
+}
+
+func (s postgres) ForeignKeys(tableName string) ([]ForeignKey, error) {
+	return queryForeignKeys(s.db, `SELECT con.conname, a.attname, rc.relname, ra.attname FROM pg_constraint con
+	CROSS JOIN LATERAL unnest(con.conkey, con.confkey) WITH ORDINALITY AS k(col, refcol, pos)
+	JOIN pg_attribute a ON a.attrelid = con.conrelid AND a.attnum = k.col
+	JOIN pg_class rc ON rc.oid = con.confrelid
+	JOIN pg_attribute ra ON ra.attrelid = con.confrelid AND ra.attnum = k.refcol
+	WHERE con.contype = 'f' AND con.conrelid = quote_ident($1)::regclass
+	ORDER BY con.conname, k.pos`, tableName)
+}
+
 func (s postgres) HasTable(tableName string) bool {
 	var count int
 	s.db.QueryRow("SELECT count(*) FROM INFORMATION_SCHEMA.tables WHERE table_name = $1 AND table_type = 'BASE TABLE'", tableName).Scan(&count)
@@ -107,6 +165,100 @@ func (s postgres) HasColumn(tableName string, columnName string) bool {
 	return count > 0
 }
 
+// postgresDataTypes names of postgres internal types used by `DataTypeOf`
+var postgresDataTypes = map[string]string{
+	"int2":        "smallint",
+	"int4":        "integer",
+	"int8":        "bigint",
+	"bool":        "boolean",
+	"float4":      "real",
+	"float8":      "double precision",
+	"bpchar":      "char",
+	"timestamptz": "timestamp with time zone",
+	"timetz":      "time with time zone",
+}
+
+// postgresDataTypeAliases aliases of types written in tags or returned by the server, `DataTypeOf` writes their canonical names
+var postgresDataTypeAliases = map[string]string{
+	"int":                         "integer",
+	"decimal":                     "numeric",
+	"float":                       "double precision",
+	"character varying":           "varchar",
+	"character":                   "char",
+	"timestamp without time zone": "timestamp",
+	"time without time zone":      "time",
+	"serial2":                     "smallserial",
+	"serial4":                     "serial",
+	"serial8":                     "bigserial",
+}
+
+// postgresSerialTypes serial types of integer types, used when the column's default is a sequence
+var postgresSerialTypes = map[string]string{
+	"smallint": "smallserial",
+	"integer":  "serial",
+	"bigint":   "bigserial",
+}
+
+func (s postgres) ColumnTypes(tableName string) ([]ColumnType, error) {
+	columnTypes, err := queryColumnTypes(s.db, `SELECT c.column_name, c.udt_name, c.character_maximum_length, c.is_nullable, c.column_default,
+	(SELECT count(*) FROM pg_index i JOIN pg_attribute a ON a.attrelid = i.indrelid AND a.attnum = ANY(i.indkey)
+	WHERE i.indrelid = quote_ident(c.table_name)::regclass AND i.indisprimary AND a.attname = c.column_name)
+	FROM INFORMATION_SCHEMA.columns c WHERE c.table_schema = CURRENT_SCHEMA() AND c.table_name = $1 ORDER BY c.ordinal_position`, tableName)
+
+	for idx, columnType := range columnTypes {
+		if dataType, ok := postgresDataTypes[columnType.DataType]; ok {
+			columnType.DataType, columnType.Type = dataType, dataType
+			if columnType.Length > 0 {
+				columnType.Type = fmt.Sprintf("%v(%d)", dataType, columnType.Length)
+			}
+		}
+
+		if columnType.Default != nil {
+			value := *columnType.Default
+			if strings.HasPrefix(value, "nextval(") {
+				if serialType, ok := postgresSerialTypes[columnType.DataType]; ok {
+					columnType.DataType, columnType.Type = serialType, serialType
+				}
+				columnType.Default = nil
+			} else {
+				// remove type casts like `'value'::character varying`
+				for i := strings.LastIndex(value, "::"); i > 0 && !strings.ContainsAny(value[i:], "')"); i = strings.LastIndex(value, "::") {
+					value = value[:i]
+				}
+				columnType.Default = &value
+			}
+		}
+		columnTypes[idx] = columnType
+	}
+	return columnTypes, err
+}
+
+// NormalizeColumnType postgres names types with internal names too, e.g. `int4` is `integer`
+func (postgres) NormalizeColumnType(column ColumnType) ColumnType {
+	if dataType, ok := postgresDataTypes[column.DataType]; ok {
+		column.DataType = dataType
+	}
+	return normalizeDataType(column, postgresDataTypeAliases)
+}
+
+func (s postgres) Comments(tableName string) (string, map[string]string, error) {
+	var tableComment sql.NullString
+	if err := s.db.QueryRow("SELECT obj_description(quote_ident($1)::regclass, 'pg_class')", tableName).Scan(&tableComment); err != nil {
+		return "", nil, err
+	}
+
+	columnComments, err := queryColumnComments(s.db, "SELECT attname, col_description(attrelid, attnum) FROM pg_attribute WHERE attrelid = quote_ident($1)::regclass AND attnum > 0 AND NOT attisdropped", tableName)
+	return tableComment.String, columnComments, err
+}
+
+func (s postgres) TableCommentSQL(tableName string, comment string) string {
+	return fmt.Sprintf("COMMENT ON TABLE %v IS %v", s.Quote(tableName), s.QuoteValue(comment))
+}
+
+func (s postgres) ColumnCommentSQL(tableName string, columnName string, definition string, comment string) string {
+	return fmt.Sprintf("COMMENT ON COLUMN %v.%v IS %v", s.Quote(tableName), s.Quote(columnName), s.QuoteValue(comment))
+}
+
 func (s postgres) CurrentDatabase() (name string) {
 	s.db.QueryRow("SELECT CURRENT_DATABASE()").Scan(&name)
 	return
@@ -116,6 +268,10 @@ func (s postgres) LastInsertIDReturningSuffix(tableName, key string) string {
 	return fmt.Sprintf("RETURNING %v.%v", tableName, key)
 }
 
+func (postgres) MaxBindVars() int {
+	return 65535
+}
+
 func (postgres) SupportLastInsertID() bool {
 	return false
 }
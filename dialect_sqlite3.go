@@ -1,6 +1,7 @@
 package gorm
 
 import (
+	"database/sql"
 	"fmt"
 	"reflect"
 	"strings"
@@ -76,6 +77,64 @@ func (s sqlite3) HasIndex(tableName string, indexName string) bool {
 	return count > 0
 }
 
+// RenameIndexSQL sqlite can't rename indexes, the index is recreated with the new name
+func (s sqlite3) RenameIndexSQL(tableName string, oldName string, newName string) ([]string, error) {
+	var sql string
+	s.db.QueryRow("SELECT sql FROM sqlite_master WHERE type = 'index' AND tbl_name = ? AND name = ?", tableName, oldName).Scan(&sql)
+
+	definition := fmt.Sprintf("INDEX %v ON", oldName)
+	if !strings.Contains(sql, definition) {
+		return nil, ErrUnsupportedRename
+	}
+
+	return []string{
+		fmt.Sprintf("DROP INDEX %v", oldName),
+		strings.Replace(sql, definition, fmt.Sprintf("INDEX %v ON", newName), 1),
+	}, nil
+}
+
+func (s sqlite3) HasForeignKey(tableName string, foreignKeyName string) bool {
+	var count int
+	s.db.QueryRow("SELECT count(*) FROM sqlite_master WHERE type='table' AND tbl_name = ? AND sql LIKE ?", tableName, fmt.Sprintf("%%CONSTRAINT %v FOREIGN KEY%%", s.Quote(foreignKeyName))).Scan(&count)
+	return count > 0
+}
+
+// InlineForeignKeys sqlite can't add constraints to existing tables
+func (sqlite3) InlineForeignKeys() bool {
+	return true
+}
+
+func (s sqlite3) HasConstraint(tableName string, constraintName string) bool {
+	var count int
+	s.db.QueryRow("SELECT count(*) FROM sqlite_master WHERE type='table' AND tbl_name = ? AND sql LIKE ?", tableName, fmt.Sprintf("%%CONSTRAINT %v %%", s.Quote(constraintName))).Scan(&count)
+	return count > 0
+}
+
+// CreateConstraintSQL sqlite can't add constraints to existing tables, the table needs to be recreated
+func (sqlite3) CreateConstraintSQL(tableName string, constraintName string, definition string) (string, error) {
+	return "", ErrUnsupportedConstraint
+}
+
+// DropConstraintSQL sqlite can't drop constraints from existing tables, the table needs to be recreated
+func (sqlite3) DropConstraintSQL(tableName string, constraintName string) (string, error) {
+	return "", ErrUnsupportedConstraint
+}
+
+func (s sqlite3) TableNames() ([]string, error) {
+	return queryTableNames(s.db, "SELECT name FROM sqlite_master WHERE type='table' AND name NOT LIKE 'sqlite_%' ORDER BY name")
+}
+
+// Indexes indexes created for primary keys are excluded
+func (s sqlite3) Indexes(tableName string) ([]TableIndex, error) {
+	return queryIndexes(s.db, `SELECT il.name, il."unique", ii.name FROM pragma_index_list(?) il JOIN pragma_index_info(il.name) ii
+	WHERE il.origin <> 'pk' ORDER BY il.name, ii.seqno`, tableName)
+}
+
+// ForeignKeys sqlite doesn't keep names of foreign keys, they are named by their ids
+func (s sqlite3) ForeignKeys(tableName string) ([]ForeignKey, error) {
+	return queryForeignKeys(s.db, `SELECT 'fk_' || id, "from", "table", "to" FROM pragma_foreign_key_list(?) ORDER BY id, seq`, tableName)
+}
+
 func (s sqlite3) HasTable(tableName string) bool {
 	var count int
 	s.db.QueryRow("SELECT count(*) FROM sqlite_master WHERE type='table' AND name=?", tableName).Scan(&count)
@@ -88,6 +147,47 @@ func (s sqlite3) HasColumn(tableName string, columnName string) bool {
 	return count > 0
 }
 
+func (s sqlite3) ColumnTypes(tableName string) ([]ColumnType, error) {
+	rows, err := s.db.Query(fmt.Sprintf("PRAGMA table_info(%v)", s.Quote(tableName)))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columnTypes []ColumnType
+	for rows.Next() {
+		var (
+			columnType   ColumnType
+			cid, notNull int
+			defaultValue sql.NullString
+			primaryKey   int
+		)
+
+		if err := rows.Scan(&cid, &columnType.Name, &columnType.Type, &notNull, &defaultValue, &primaryKey); err != nil {
+			return nil, err
+		}
+
+		columnType.DataType, columnType.Length = parseColumnType(columnType.Type)
+		columnType.Nullable = notNull == 0 && primaryKey == 0
+		if defaultValue.Valid {
+			columnType.Default = &defaultValue.String
+		}
+		columnType.PrimaryKey = primaryKey > 0
+		columnTypes = append(columnTypes, columnType)
+	}
+	return columnTypes, rows.Err()
+}
+
+// LockingSQL sqlite locks the whole database when writing, so rows are not locked
+func (sqlite3) LockingSQL(strength string, options string) (string, bool, error) {
+	return "", false, nil
+}
+
+// AlterColumnSQL sqlite can't change columns, the table needs to be recreated
+func (sqlite3) AlterColumnSQL(tableName string, from, to ColumnType) ([]string, error) {
+	return nil, ErrUnsupportedAlterColumn
+}
+
 func (s sqlite3) CurrentDatabase() (name string) {
 	var (
 		ifaces   = make([]interface{}, 3)
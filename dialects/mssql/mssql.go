@@ -1,6 +1,7 @@
 package mssql
 
 import (
+	"database/sql"
 	"fmt"
 	"reflect"
 	"strconv"
@@ -9,6 +10,7 @@ import (
 
 	_ "github.com/denisenkom/go-mssqldb"
 	"github.com/jinzhu/gorm"
+	"github.com/jinzhu/gorm/clause"
 )
 
 func setIdentityInsert(scope *gorm.Scope) {
@@ -57,6 +59,28 @@ func (mssql) Quote(key string) string {
 	return fmt.Sprintf(`"%s"`, key)
 }
 
+func (mssql) QuoteValue(value interface{}) string {
+	switch v := gorm.ParseValueForDialect(value).(type) {
+	case nil:
+		return "NULL"
+	case bool:
+		if v {
+			return "1"
+		}
+		return "0"
+	case int64, uint64:
+		return fmt.Sprint(v)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case []byte:
+		return fmt.Sprintf("0x%x", v)
+	case time.Time:
+		return fmt.Sprintf("'%v'", v.Format("2006-01-02 15:04:05.9999999"))
+	default:
+		return "N'" + strings.Replace(fmt.Sprint(v), "'", "''", -1) + "'"
+	}
+}
+
 func (s *mssql) DataTypeOf(field *gorm.StructField) string {
 	var dataValue, sqlType, size, additionalType = gorm.ParseFieldStructForDialect(field, s)
 
@@ -111,6 +135,36 @@ func (s *mssql) DataTypeOf(field *gorm.StructField) string {
 	return fmt.Sprintf("%v %v", sqlType, additionalType)
 }
 
+// CreateIndexSQL mssql doesn't support expression indexes, types could be `clustered` or `nonclustered`
+func (mssql) CreateIndexSQL(tableName string, index gorm.Index) (string, error) {
+	sqlCreate := "CREATE"
+	if index.Unique {
+		sqlCreate += " UNIQUE"
+	}
+
+	switch typ := strings.ToUpper(index.Type); typ {
+	case "":
+	case "CLUSTERED", "NONCLUSTERED":
+		sqlCreate += " " + typ
+	default:
+		return "", gorm.ErrUnsupportedIndex
+	}
+
+	var columns []string
+	for _, column := range index.Columns {
+		if column.Expression != "" {
+			return "", gorm.ErrUnsupportedIndex
+		}
+		columns = append(columns, strings.TrimSpace(column.Name+" "+column.Sort))
+	}
+
+	sql := fmt.Sprintf("%v INDEX %v ON %v(%v)", sqlCreate, index.Name, tableName, strings.Join(columns, ", "))
+	if index.Where != "" {
+		sql += " WHERE " + index.Where
+	}
+	return sql, nil
+}
+
 func (s mssql) HasIndex(tableName string, indexName string) bool {
 	var count int
 	s.db.QueryRow("SELECT count(*) FROM sys.indexes WHERE name=? AND object_id=OBJECT_ID(?)", indexName, tableName).Scan(&count)
@@ -122,10 +176,42 @@ func (s mssql) RemoveIndex(tableName string, indexName string) error {
 	return err
 }
 
+func (s mssql) RenameIndexSQL(tableName string, oldName string, newName string) ([]string, error) {
+	return []string{fmt.Sprintf("EXEC sp_rename %v, %v, 'INDEX'", s.QuoteValue(tableName+"."+oldName), s.QuoteValue(newName))}, nil
+}
+
+func (s mssql) RenameTableSQL(oldName string, newName string) string {
+	return fmt.Sprintf("EXEC sp_rename %v, %v", s.QuoteValue(oldName), s.QuoteValue(newName))
+}
+
+func (s mssql) RenameColumnSQL(tableName string, oldName string, newName string) (string, error) {
+	return fmt.Sprintf("EXEC sp_rename %v, %v, 'COLUMN'", s.QuoteValue(tableName+"."+oldName), s.QuoteValue(newName)), nil
+}
+
 func (s mssql) HasForeignKey(tableName string, foreignKeyName string) bool {
+	var count int
+	s.db.QueryRow("SELECT count(*) FROM sys.foreign_keys WHERE name=? AND parent_object_id=OBJECT_ID(?)", foreignKeyName, tableName).Scan(&count)
+	return count > 0
+}
+
+func (mssql) InlineForeignKeys() bool {
 	return false
 }
 
+func (s mssql) HasConstraint(tableName string, constraintName string) bool {
+	var count int
+	s.db.QueryRow("SELECT count(*) FROM sys.objects WHERE name=? AND parent_object_id=OBJECT_ID(?)", constraintName, tableName).Scan(&count)
+	return count > 0
+}
+
+func (s mssql) CreateConstraintSQL(tableName string, constraintName string, definition string) (string, error) {
+	return fmt.Sprintf("ALTER TABLE %v ADD CONSTRAINT %v %v", s.Quote(tableName), s.Quote(constraintName), definition), nil
+}
+
+func (s mssql) DropConstraintSQL(tableName string, constraintName string) (string, error) {
+	return fmt.Sprintf("ALTER TABLE %v DROP CONSTRAINT %v", s.Quote(tableName), s.Quote(constraintName)), nil
+}
+
 func (s mssql) HasTable(tableName string) bool {
 	var count int
 	s.db.QueryRow("SELECT count(*) FROM INFORMATION_SCHEMA.tables WHERE table_name = ? AND table_catalog = ?", tableName, s.CurrentDatabase()).Scan(&count)
@@ -138,11 +224,283 @@ func (s mssql) HasColumn(tableName string, columnName string) bool {
 	return count > 0
 }
 
+func (s mssql) ColumnTypes(tableName string) ([]gorm.ColumnType, error) {
+	rows, err := s.db.Query(`SELECT c.COLUMN_NAME, c.DATA_TYPE, c.CHARACTER_MAXIMUM_LENGTH, c.IS_NULLABLE, c.COLUMN_DEFAULT,
+	(SELECT count(*) FROM INFORMATION_SCHEMA.TABLE_CONSTRAINTS tc JOIN INFORMATION_SCHEMA.KEY_COLUMN_USAGE kcu ON tc.CONSTRAINT_NAME = kcu.CONSTRAINT_NAME AND tc.TABLE_SCHEMA = kcu.TABLE_SCHEMA AND tc.TABLE_NAME = kcu.TABLE_NAME
+	WHERE tc.CONSTRAINT_TYPE = 'PRIMARY KEY' AND kcu.TABLE_SCHEMA = c.TABLE_SCHEMA AND kcu.TABLE_NAME = c.TABLE_NAME AND kcu.COLUMN_NAME = c.COLUMN_NAME)
+	FROM INFORMATION_SCHEMA.COLUMNS c WHERE c.TABLE_CATALOG = ? AND c.TABLE_NAME = ? ORDER BY c.ORDINAL_POSITION`, s.CurrentDatabase(), tableName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columnTypes []gorm.ColumnType
+	for rows.Next() {
+		var (
+			columnType   gorm.ColumnType
+			length       sql.NullInt64
+			nullable     string
+			defaultValue sql.NullString
+			primaryKeys  int
+		)
+
+		if err := rows.Scan(&columnType.Name, &columnType.DataType, &length, &nullable, &defaultValue, &primaryKeys); err != nil {
+			return nil, err
+		}
+
+		columnType.DataType = strings.ToLower(columnType.DataType)
+		columnType.Type = columnType.DataType
+		// the length of `nvarchar(max)` is -1
+		if length.Valid && length.Int64 > 0 {
+			columnType.Length = int(length.Int64)
+			columnType.Type = fmt.Sprintf("%v(%d)", columnType.DataType, columnType.Length)
+		}
+		columnType.Nullable = strings.EqualFold(nullable, "YES")
+
+		// defaults are returned in parentheses, e.g. `((10))`, `(N'value')`
+		if defaultValue.Valid {
+			value := defaultValue.String
+			for len(value) > 1 && strings.HasPrefix(value, "(") && strings.HasSuffix(value, ")") {
+				value = value[1 : len(value)-1]
+			}
+			if strings.HasPrefix(value, "N'") {
+				value = value[1:]
+			}
+			columnType.Default = &value
+		}
+		columnType.PrimaryKey = primaryKeys > 0
+		columnTypes = append(columnTypes, columnType)
+	}
+	return columnTypes, rows.Err()
+}
+
+// mssqlDataTypeAliases aliases of types written in tags, mssql reports their canonical names
+var mssqlDataTypeAliases = map[string]string{
+	"integer":                    "int",
+	"dec":                        "decimal",
+	"numeric":                    "decimal",
+	"double precision":           "float",
+	"character varying":          "varchar",
+	"character":                  "char",
+	"national character varying": "nvarchar",
+	"national character":         "nchar",
+	"rowversion":                 "timestamp",
+}
+
+func (mssql) NormalizeColumnType(column gorm.ColumnType) gorm.ColumnType {
+	if dataType, ok := mssqlDataTypeAliases[column.DataType]; ok {
+		column.DataType = dataType
+	}
+	return column
+}
+
+func (s mssql) AlterColumnSQL(tableName string, from, to gorm.ColumnType) ([]string, error) {
+	var (
+		sqls           []string
+		typeChanged    = !from.SameType(to) || from.Nullable != to.Nullable
+		defaultChanged = !from.SameDefault(to)
+	)
+
+	// defaults are constraints in mssql, they have to be dropped before changing the column
+	if from.Default != nil && (typeChanged || defaultChanged) {
+		sqls = append(sqls, fmt.Sprintf(`DECLARE @name sysname;
+SELECT @name = d.name FROM sys.default_constraints d JOIN sys.columns c ON d.parent_object_id = c.object_id AND d.parent_column_id = c.column_id
+WHERE d.parent_object_id = OBJECT_ID('%v') AND c.name = '%v';
+IF @name IS NOT NULL EXEC('ALTER TABLE %v DROP CONSTRAINT ' + QUOTENAME(@name))`, tableName, to.Name, s.Quote(tableName)))
+	}
+
+	if typeChanged {
+		nullable := "NULL"
+		if !to.Nullable {
+			nullable = "NOT NULL"
+		}
+		sqls = append(sqls, fmt.Sprintf("ALTER TABLE %v ALTER COLUMN %v %v %v", s.Quote(tableName), s.Quote(to.Name), to.Type, nullable))
+	}
+
+	if to.Default != nil && (defaultChanged || from.Default != nil) {
+		sqls = append(sqls, fmt.Sprintf("ALTER TABLE %v ADD DEFAULT %v FOR %v", s.Quote(tableName), *to.Default, s.Quote(to.Name)))
+	}
+	return sqls, nil
+}
+
+func (mssql) InlineComments() bool {
+	return false
+}
+
+// Comments mssql stores comments as `MS_Description` extended properties
+func (s mssql) Comments(tableName string) (string, map[string]string, error) {
+	rows, err := s.db.Query(`SELECT COALESCE(c.name, ''), CAST(p.value AS nvarchar(max)) FROM sys.extended_properties p
+	LEFT JOIN sys.columns c ON c.object_id = p.major_id AND c.column_id = p.minor_id
+	WHERE p.class = 1 AND p.major_id = OBJECT_ID(?) AND p.name = 'MS_Description'`, tableName)
+	if err != nil {
+		return "", nil, err
+	}
+	defer rows.Close()
+
+	var (
+		tableComment   string
+		columnComments = map[string]string{}
+	)
+	for rows.Next() {
+		var columnName, comment string
+		if err := rows.Scan(&columnName, &comment); err != nil {
+			return "", nil, err
+		}
+
+		if columnName == "" {
+			tableComment = comment
+		} else {
+			columnComments[columnName] = comment
+		}
+	}
+	return tableComment, columnComments, rows.Err()
+}
+
+func (s mssql) TableCommentSQL(tableName string, comment string) string {
+	return s.descriptionSQL(tableName, "", comment)
+}
+
+func (s mssql) ColumnCommentSQL(tableName string, columnName string, definition string, comment string) string {
+	return s.descriptionSQL(tableName, columnName, comment)
+}
+
+// descriptionSQL return sql to add or update the `MS_Description` extended property of the table, or its column if columnName isn't blank
+func (s mssql) descriptionSQL(tableName string, columnName string, comment string) string {
+	var (
+		minorID = "0"
+		level2  string
+	)
+	if columnName != "" {
+		minorID = fmt.Sprintf("COLUMNPROPERTY(OBJECT_ID(%v), %v, 'ColumnId')", s.QuoteValue(tableName), s.QuoteValue(columnName))
+		level2 = fmt.Sprintf(", @level2type = N'COLUMN', @level2name = %v", s.QuoteValue(columnName))
+	}
+
+	arguments := fmt.Sprintf("@name = N'MS_Description', @value = %v, @level0type = N'SCHEMA', @level0name = @schema, @level1type = N'TABLE', @level1name = %v%v", s.QuoteValue(comment), s.QuoteValue(tableName), level2)
+	return fmt.Sprintf(`DECLARE @schema sysname = SCHEMA_NAME();
+IF EXISTS (SELECT * FROM sys.extended_properties WHERE class = 1 AND major_id = OBJECT_ID(%v) AND minor_id = %v AND name = 'MS_Description')
+EXEC sp_updateextendedproperty %v
+ELSE EXEC sp_addextendedproperty %v`, s.QuoteValue(tableName), minorID, arguments, arguments)
+}
+
+func (s mssql) TableNames() ([]string, error) {
+	rows, err := s.db.Query("SELECT TABLE_NAME FROM INFORMATION_SCHEMA.TABLES WHERE TABLE_CATALOG = ? AND TABLE_TYPE = 'BASE TABLE' ORDER BY TABLE_NAME", s.CurrentDatabase())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tableNames []string
+	for rows.Next() {
+		var tableName string
+		if err := rows.Scan(&tableName); err != nil {
+			return nil, err
+		}
+		tableNames = append(tableNames, tableName)
+	}
+	return tableNames, rows.Err()
+}
+
+func (s mssql) Indexes(tableName string) ([]gorm.TableIndex, error) {
+	rows, err := s.db.Query(`SELECT i.name, i.is_unique, c.name FROM sys.indexes i
+	JOIN sys.index_columns ic ON ic.object_id = i.object_id AND ic.index_id = i.index_id
+	JOIN sys.columns c ON c.object_id = ic.object_id AND c.column_id = ic.column_id
+	WHERE i.object_id = OBJECT_ID(?) AND i.is_primary_key = 0 AND i.type > 0 AND ic.is_included_column = 0
+	ORDER BY i.name, ic.key_ordinal`, tableName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var indexes []gorm.TableIndex
+	for rows.Next() {
+		var index gorm.TableIndex
+		var column string
+		if err := rows.Scan(&index.Name, &index.Unique, &column); err != nil {
+			return nil, err
+		}
+
+		if last := len(indexes) - 1; last >= 0 && indexes[last].Name == index.Name {
+			indexes[last].Columns = append(indexes[last].Columns, column)
+		} else {
+			index.Columns = []string{column}
+			indexes = append(indexes, index)
+		}
+	}
+	return indexes, rows.Err()
+}
+
+func (s mssql) ForeignKeys(tableName string) ([]gorm.ForeignKey, error) {
+	rows, err := s.db.Query(`SELECT fk.name, c.name, OBJECT_NAME(fk.referenced_object_id), rc.name FROM sys.foreign_keys fk
+	JOIN sys.foreign_key_columns fkc ON fkc.constraint_object_id = fk.object_id
+	JOIN sys.columns c ON c.object_id = fkc.parent_object_id AND c.column_id = fkc.parent_column_id
+	JOIN sys.columns rc ON rc.object_id = fkc.referenced_object_id AND rc.column_id = fkc.referenced_column_id
+	WHERE fk.parent_object_id = OBJECT_ID(?)
+	ORDER BY fk.name, fkc.constraint_column_id`, tableName)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var foreignKeys []gorm.ForeignKey
+	for rows.Next() {
+		var foreignKey gorm.ForeignKey
+		var column, refColumn string
+		if err := rows.Scan(&foreignKey.Name, &column, &foreignKey.RefTable, &refColumn); err != nil {
+			return nil, err
+		}
+
+		if last := len(foreignKeys) - 1; last >= 0 && foreignKeys[last].Name == foreignKey.Name {
+			foreignKeys[last].Columns = append(foreignKeys[last].Columns, column)
+			foreignKeys[last].RefColumns = append(foreignKeys[last].RefColumns, refColumn)
+		} else {
+			foreignKey.Columns, foreignKey.RefColumns = []string{column}, []string{refColumn}
+			foreignKeys = append(foreignKeys, foreignKey)
+		}
+	}
+	return foreignKeys, rows.Err()
+}
+
 func (s mssql) CurrentDatabase() (name string) {
 	s.db.QueryRow("SELECT DB_NAME() AS [Current Database]").Scan(&name)
 	return
 }
 
+// BuildClause write limit with `OFFSET` and `FETCH NEXT`, mssql doesn't support `LIMIT`
+func (s mssql) BuildClause(c clause.Interface, builder clause.Builder) bool {
+	if limit, ok := c.(clause.Limit); ok {
+		builder.WriteString(strings.TrimSpace(s.LimitAndOffsetSQL(limit.Limit, limit.Offset)))
+		return true
+	}
+	return false
+}
+
+// LockingSQL mssql locks rows with table hints, e.g. `WITH (UPDLOCK, READPAST)`, locked rows can't be skipped when holding shared locks
+func (mssql) LockingSQL(strength string, options string) (string, bool, error) {
+	var hints []string
+	switch strength {
+	case clause.LockingStrengthUpdate:
+		hints = append(hints, "UPDLOCK")
+	case clause.LockingStrengthShare:
+		hints = append(hints, "HOLDLOCK")
+	default:
+		return "", false, gorm.ErrUnsupportedLocking
+	}
+
+	switch options {
+	case "":
+	case clause.LockingOptionsNoWait:
+		hints = append(hints, "NOWAIT")
+	case clause.LockingOptionsSkipLocked:
+		if strength == clause.LockingStrengthShare {
+			return "", false, gorm.ErrUnsupportedLocking
+		}
+		hints = append(hints, "READPAST")
+	default:
+		return "", false, gorm.ErrUnsupportedLocking
+	}
+	return fmt.Sprintf("WITH (%v)", strings.Join(hints, ", ")), true, nil
+}
+
 func (mssql) LimitAndOffsetSQL(limit, offset interface{}) (sql string) {
 	if offset != nil {
 		if parsedOffset, err := strconv.ParseInt(fmt.Sprint(offset), 0, 0); err == nil && parsedOffset > 0 {
@@ -168,3 +526,51 @@ func (mssql) SelectFromDummyTable() string {
 func (mssql) LastInsertIDReturningSuffix(tableName, columnName string) string {
 	return ""
 }
+
+// MaxBindVars mssql allows 2100 parameters and 1000 rows in an insert statement, limiting bind vars to 1000 keeps both limits as every row has at least one bind var
+func (mssql) MaxBindVars() int {
+	return 1000
+}
+
+func (mssql) BatchFirstInsertID(lastInsertID int64, count int) int64 {
+	return lastInsertID - int64(count) + 1
+}
+
+func (s mssql) UpsertSQL(tableName string, columns []string, values string, onConflict gorm.OnConflict, where string) (string, error) {
+	if len(onConflict.Columns) == 0 {
+		return "", gorm.ErrUnsupportedOnConflict
+	}
+
+	var conditions, sets, excludedColumns []string
+	for _, column := range onConflict.Columns {
+		conditions = append(conditions, fmt.Sprintf("%v.%v = excluded.%v", tableName, s.Quote(column), s.Quote(column)))
+	}
+
+	for _, column := range onConflict.UpdateColumns {
+		sets = append(sets, fmt.Sprintf("%v = excluded.%v", s.Quote(column), s.Quote(column)))
+	}
+
+	for _, column := range columns {
+		excludedColumns = append(excludedColumns, "excluded."+column)
+	}
+
+	sql := fmt.Sprintf("MERGE INTO %v WITH (HOLDLOCK) USING (VALUES %v) AS excluded (%v) ON %v", tableName, values, strings.Join(columns, ","), strings.Join(conditions, " AND "))
+
+	if !onConflict.DoNothing {
+		sql += " WHEN MATCHED"
+		if where != "" {
+			sql += " AND " + where
+		}
+		sql += " THEN UPDATE SET " + strings.Join(sets, ", ")
+	}
+
+	return fmt.Sprintf("%v WHEN NOT MATCHED THEN INSERT (%v) VALUES (%v);", sql, strings.Join(columns, ","), strings.Join(excludedColumns, ",")), nil
+}
+
+func (mssql) SavePointSQL(name string) string {
+	return fmt.Sprintf("SAVE TRANSACTION %v", name)
+}
+
+func (mssql) RollbackToSavePointSQL(name string) string {
+	return fmt.Sprintf("ROLLBACK TRANSACTION %v", name)
+}
@@ -16,6 +16,24 @@ var (
 	ErrCantStartTransaction = errors.New("can't start transaction")
 	// ErrUnaddressable unaddressable value
 	ErrUnaddressable = errors.New("using unaddressable value")
+	// ErrStaleObject stale object error, happens when updating a record whose version has been changed since it was loaded
+	ErrStaleObject = errors.New("stale object")
+	// ErrUnsupportedOnConflict unsupported `OnConflict` option, happens when current dialect can't render it, e.g. mysql doesn't support `Where`, mssql requires `Columns`
+	ErrUnsupportedOnConflict = errors.New("unsupported on conflict option")
+	// ErrMissingConflictColumns missing conflict columns error, happens when upserting records without `Columns` of `OnConflict`, and the model doesn't have primary keys or unique indexes to resolve conflicts on
+	ErrMissingConflictColumns = errors.New("missing conflict columns")
+	// ErrUnsupportedClause unsupported clause, happens when passing an unknown value to `Clauses`, or using clauses with raw sql
+	ErrUnsupportedClause = errors.New("unsupported clause")
+	// ErrUnsupportedAlterColumn unsupported alter column error, happens when current dialect can't change the definition of a column, e.g. sqlite
+	ErrUnsupportedAlterColumn = errors.New("unsupported alter column")
+	// ErrUnsupportedConstraint unsupported constraint error, happens when current dialect can't add constraints to or drop constraints from existing tables, e.g. sqlite
+	ErrUnsupportedConstraint = errors.New("unsupported constraint")
+	// ErrUnsupportedIndex unsupported index error, happens when current dialect can't render an index option, e.g. mysql doesn't support `where`, sqlite doesn't support `type`
+	ErrUnsupportedIndex = errors.New("unsupported index option")
+	// ErrUnsupportedRename unsupported rename error, happens when current dialect can't rename the object, e.g. sqlite can't rename indexes created by constraints
+	ErrUnsupportedRename = errors.New("unsupported rename")
+	// ErrUnsupportedLocking unsupported locking error, happens when `Locking` doesn't have a strength, or current dialect can't lock rows with the strength and options, e.g. mssql can't skip locked rows with shared locks
+	ErrUnsupportedLocking = errors.New("unsupported locking")
 )
 
 // Errors contains all happened errors
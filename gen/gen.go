@@ -0,0 +1,272 @@
+// Package gen generates model structs from tables of an existing database through the catalog methods of dialects, e.g:
+//     source, err := gen.Generate(db, gen.Config{PackageName: "models", Tables: []string{"users", "orders"}})
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+	"github.com/jinzhu/inflection"
+)
+
+// Config options of generated code
+type Config struct {
+	// PackageName package of generated code, defaults to `models`
+	PackageName string
+	// Tables names of tables to generate models for, defaults to all tables of current database
+	Tables []string
+}
+
+// Model a struct generated from a table
+type Model struct {
+	Name      string
+	TableName string
+	Fields    []Field
+}
+
+// Field a field of a generated struct
+type Field struct {
+	Name string
+	// Type go type of the field, e.g. `string`, `*time.Time`, `[]Order`
+	Type string
+	// Tag value of the field's `gorm` tag, e.g. `column:name;type:varchar(64);size:64;not null`
+	Tag string
+}
+
+// commonInitialisms words written in upper case in field names, e.g. `user_id` is `UserID`
+var commonInitialisms = map[string]bool{"API": true, "HTML": true, "HTTP": true, "ID": true, "IP": true, "JSON": true, "SQL": true, "UID": true, "URL": true, "UUID": true, "XML": true}
+
+// Inspect build models from tables, foreign keys between these tables become belongs_to and has_many fields
+func Inspect(db *gorm.DB, tableNames ...string) ([]Model, error) {
+	dialect := db.Dialect()
+	if len(tableNames) == 0 {
+		var err error
+		if tableNames, err = dialect.TableNames(); err != nil {
+			return nil, err
+		}
+	}
+
+	var (
+		models      = make([]*Model, len(tableNames))
+		tableModels = map[string]*Model{}
+		primaryKeys = map[string]string{}
+	)
+	for idx, tableName := range tableNames {
+		columns, err := dialect.ColumnTypes(tableName)
+		if err != nil {
+			return nil, err
+		}
+
+		indexes, err := dialect.Indexes(tableName)
+		if err != nil {
+			return nil, err
+		}
+
+		model := &Model{Name: modelName(tableName), TableName: tableName}
+		for _, column := range columns {
+			model.addField(Field{Name: fieldName(column.Name), Type: goType(column), Tag: columnTag(column, indexes)})
+			if column.PrimaryKey {
+				primaryKeys[tableName] = column.Name
+			}
+		}
+		models[idx], tableModels[tableName] = model, model
+	}
+
+	for _, model := range models {
+		foreignKeys, err := dialect.ForeignKeys(model.TableName)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, foreignKey := range foreignKeys {
+			refModel, ok := tableModels[foreignKey.RefTable]
+			// composite foreign keys can't be declared with tags
+			if !ok || len(foreignKey.Columns) != 1 {
+				continue
+			}
+
+			foreignKeyField := fieldName(foreignKey.Columns[0])
+			tag := "foreignkey:" + foreignKeyField
+			if refColumn := foreignKey.RefColumns[0]; refColumn != primaryKeys[foreignKey.RefTable] {
+				tag += ";association_foreignkey:" + fieldName(refColumn)
+			}
+
+			// `company_id` belongs to `Company`
+			name := strings.TrimSuffix(foreignKeyField, "ID")
+			if name == "" || name == foreignKeyField {
+				name = refModel.Name
+			}
+			model.addField(Field{Name: name, Type: "*" + refModel.Name, Tag: tag})
+			refModel.addField(Field{Name: inflection.Plural(model.Name), Type: "[]" + model.Name, Tag: tag})
+		}
+	}
+
+	results := make([]Model, len(models))
+	for idx, model := range models {
+		results[idx] = *model
+	}
+	return results, nil
+}
+
+// Generate return formatted go source of models built from tables
+func Generate(db *gorm.DB, config Config) ([]byte, error) {
+	models, err := Inspect(db, config.Tables...)
+	if err != nil {
+		return nil, err
+	}
+
+	packageName := config.PackageName
+	if packageName == "" {
+		packageName = "models"
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "// Code generated by gorm-gen from tables of the database.\n\npackage %v\n\n", packageName)
+	for _, model := range models {
+		if model.usesTime() {
+			buf.WriteString("import \"time\"\n\n")
+			break
+		}
+	}
+
+	for _, model := range models {
+		fmt.Fprintf(&buf, "// %v model of table `%v`\ntype %v struct {\n", model.Name, model.TableName, model.Name)
+		for _, field := range model.Fields {
+			fmt.Fprintf(&buf, "%v %v `gorm:%q`\n", field.Name, field.Type, field.Tag)
+		}
+		fmt.Fprintf(&buf, "}\n\n// TableName name of the table of %v\nfunc (%v) TableName() string {\nreturn %q\n}\n\n", model.Name, model.Name, model.TableName)
+	}
+	return format.Source(buf.Bytes())
+}
+
+// addField add the field unless the model has a field with the same name
+func (model *Model) addField(field Field) {
+	for _, f := range model.Fields {
+		if f.Name == field.Name {
+			return
+		}
+	}
+	model.Fields = append(model.Fields, field)
+}
+
+func (model Model) usesTime() bool {
+	for _, field := range model.Fields {
+		if strings.HasSuffix(field.Type, "time.Time") {
+			return true
+		}
+	}
+	return false
+}
+
+// modelName return name of the model of the table, e.g. `order_items` is `OrderItem`
+func modelName(tableName string) string {
+	return fieldName(inflection.Singular(tableName))
+}
+
+// fieldName return name of the field of the column, e.g. `user_id` is `UserID`
+func fieldName(columnName string) string {
+	var name string
+	for _, part := range strings.FieldsFunc(columnName, func(c rune) bool { return c == '_' || c == ' ' || c == '-' }) {
+		if upper := strings.ToUpper(part); commonInitialisms[upper] {
+			name += upper
+		} else {
+			name += strings.Title(strings.ToLower(part))
+		}
+	}
+
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "Column" + name
+	}
+	return name
+}
+
+// goType return go type of the column, nullable columns are pointers
+func goType(column gorm.ColumnType) string {
+	var (
+		dataType = strings.ToLower(column.DataType)
+		unsigned = strings.Contains(dataType, "unsigned")
+		typ      string
+	)
+
+	switch strings.TrimSpace(strings.Replace(dataType, "unsigned", "", 1)) {
+	case "bool", "boolean", "bit":
+		typ = "bool"
+	case "tinyint":
+		typ = "int8"
+	case "smallint", "int2", "smallserial", "year":
+		typ = "int16"
+	case "int", "integer", "int4", "mediumint", "serial":
+		typ = "int"
+	case "bigint", "int8", "bigserial":
+		typ = "int64"
+	case "real", "float", "float4":
+		typ = "float32"
+	case "double", "double precision", "float8", "numeric", "decimal", "money":
+		typ = "float64"
+	case "date", "datetime", "datetime2", "smalldatetime", "datetimeoffset", "timestamp", "timestamp with time zone", "timestamp without time zone", "timestamptz":
+		typ = "time.Time"
+	case "blob", "tinyblob", "mediumblob", "longblob", "binary", "varbinary", "bytea", "image":
+		return "[]byte"
+	default:
+		typ = "string"
+	}
+
+	if unsigned && strings.HasPrefix(typ, "int") {
+		typ = "u" + typ
+	}
+
+	if column.Nullable && !column.PrimaryKey {
+		return "*" + typ
+	}
+	return typ
+}
+
+// columnTag return the `gorm` tag of the column's field, indexes with multiple columns are declared on each column with its priority
+func columnTag(column gorm.ColumnType, indexes []gorm.TableIndex) string {
+	tags := []string{"column:" + column.Name, "type:" + column.Type}
+	if column.Length > 0 {
+		tags = append(tags, fmt.Sprintf("size:%d", column.Length))
+	}
+
+	if column.PrimaryKey {
+		tags = append(tags, "primary_key")
+	} else if !column.Nullable {
+		tags = append(tags, "not null")
+	}
+
+	// tags are separated by semicolons and written in backquotes
+	if column.Default != nil && !strings.ContainsAny(*column.Default, ";`") {
+		tags = append(tags, "default:"+*column.Default)
+	}
+
+	var indexNames, uniqueIndexNames []string
+	for _, index := range indexes {
+		for idx, indexColumn := range index.Columns {
+			if indexColumn != column.Name {
+				continue
+			}
+
+			name := index.Name
+			if len(index.Columns) > 1 {
+				name += fmt.Sprintf(",priority:%d", idx+1)
+			}
+
+			if index.Unique {
+				uniqueIndexNames = append(uniqueIndexNames, name)
+			} else {
+				indexNames = append(indexNames, name)
+			}
+		}
+	}
+
+	if len(indexNames) > 0 {
+		tags = append(tags, "index:"+strings.Join(indexNames, ","))
+	}
+	if len(uniqueIndexNames) > 0 {
+		tags = append(tags, "unique_index:"+strings.Join(uniqueIndexNames, ","))
+	}
+	return strings.Join(tags, ";")
+}
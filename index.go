@@ -0,0 +1,189 @@
+package gorm
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Index definition of an index, built from index tags or `AddIndex` options, rendered by `Dialect.CreateIndexSQL`
+type Index struct {
+	Name   string
+	Unique bool
+	// Type index method or class, e.g. `btree`, `gin`, `fulltext`
+	Type string
+	// Where condition of partial index
+	Where   string
+	Columns []IndexColumn
+}
+
+// IndexColumn column or expression of an index
+type IndexColumn struct {
+	// Name quoted column name
+	Name string
+	// Expression expression indexed instead of the column, e.g. `lower(email)`
+	Expression string
+	// Sort sort direction, `ASC` or `DESC`
+	Sort string
+
+	priority int
+}
+
+// defaultIndexPriority priority of index columns without `priority` option, columns with the same priority are ordered as fields
+const defaultIndexPriority = 10
+
+// indexOptionKeys options of index tags and `AddIndex`, other values are index names or columns
+var indexOptionKeys = map[string]bool{"PRIORITY": true, "SORT": true, "WHERE": true, "TYPE": true, "EXPRESSION": true}
+
+// splitIndexOptions split the value by commas outside parentheses and quotes, e.g. `idx_name,where:status IN (1,2)`
+func splitIndexOptions(value string) (parts []string) {
+	var (
+		depth  int
+		quoted bool
+		start  int
+	)
+
+	for i, c := range value {
+		switch {
+		case c == '\'':
+			quoted = !quoted
+		case quoted:
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ',' && depth == 0:
+			parts = append(parts, value[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, value[start:])
+}
+
+// parseIndexOption return upper case key and value if the part is an index option like `sort:desc`
+func parseIndexOption(part string) (key, value string, ok bool) {
+	if kv := strings.SplitN(part, ":", 2); len(kv) == 2 {
+		key = strings.ToUpper(strings.TrimSpace(kv[0]))
+		if indexOptionKeys[key] {
+			return key, strings.TrimSpace(kv[1]), true
+		}
+	}
+	return "", "", false
+}
+
+// setOption set the option to the index or its last column
+func (index *Index) setOption(key, value string) {
+	switch key {
+	case "WHERE":
+		index.Where = value
+	case "TYPE":
+		index.Type = value
+	default:
+		if len(index.Columns) == 0 {
+			index.Columns = append(index.Columns, IndexColumn{priority: defaultIndexPriority})
+		}
+
+		column := &index.Columns[len(index.Columns)-1]
+		switch key {
+		case "PRIORITY":
+			column.priority, _ = strconv.Atoi(value)
+		case "SORT":
+			column.Sort = strings.ToUpper(value)
+		case "EXPRESSION":
+			column.Expression = value
+		}
+	}
+}
+
+// parseIndexTag parse value of tag `index` or `unique_index` of the field into indexes, each name could be followed by its options, e.g:
+//     `idx_name,priority:2,sort:desc,where:deleted_at IS NULL,type:gin,expression:lower(email)`
+// indexes with blank names are named with defaultName
+func (scope *Scope) parseIndexTag(field *StructField, value string, unique bool, defaultName string) (indexes []*Index) {
+	for _, part := range splitIndexOptions(value) {
+		if key, value, ok := parseIndexOption(part); ok {
+			if len(indexes) == 0 {
+				indexes = append(indexes, &Index{Name: defaultName, Unique: unique, Columns: []IndexColumn{{Name: scope.Quote(field.DBName), priority: defaultIndexPriority}}})
+			}
+			indexes[len(indexes)-1].setOption(key, value)
+			continue
+		}
+
+		name := strings.TrimSpace(part)
+		if name == "" || name == "INDEX" || name == "UNIQUE_INDEX" {
+			name = defaultName
+		}
+		indexes = append(indexes, &Index{Name: name, Unique: unique, Columns: []IndexColumn{{Name: scope.Quote(field.DBName), priority: defaultIndexPriority}}})
+	}
+	return indexes
+}
+
+// newIndex build an index from `AddIndex` arguments, options after a column are applied to it, e.g:
+//     "name", "sort:desc", "expression:lower(email)", "where:deleted_at IS NULL"
+func (scope *Scope) newIndex(unique bool, indexName string, columns ...string) Index {
+	index := Index{Name: indexName, Unique: unique}
+	for _, column := range columns {
+		if key, value, ok := parseIndexOption(column); ok {
+			if key == "EXPRESSION" {
+				index.Columns = append(index.Columns, IndexColumn{priority: defaultIndexPriority})
+			}
+			index.setOption(key, value)
+		} else {
+			index.Columns = append(index.Columns, IndexColumn{Name: scope.quoteIfPossible(column), priority: defaultIndexPriority})
+		}
+	}
+	return index
+}
+
+// merge add columns and options of the other index with the same name
+func (index *Index) merge(other *Index) {
+	index.Columns = append(index.Columns, other.Columns...)
+	if other.Where != "" {
+		index.Where = other.Where
+	}
+	if other.Type != "" {
+		index.Type = other.Type
+	}
+}
+
+// sortColumns sort columns by their priorities, columns with the same priority keep their order
+func (index *Index) sortColumns() {
+	sort.SliceStable(index.Columns, func(i, j int) bool {
+		return index.Columns[i].priority < index.Columns[j].priority
+	})
+}
+
+// indexColumnsSQL return columns of the index separated by commas, expressions are wrapped with parentheses
+func indexColumnsSQL(index Index) string {
+	var columns []string
+	for _, column := range index.Columns {
+		sql := column.Name
+		if column.Expression != "" {
+			sql = "(" + column.Expression + ")"
+		}
+		if column.Sort != "" {
+			sql += " " + column.Sort
+		}
+		columns = append(columns, sql)
+	}
+	return strings.Join(columns, ", ")
+}
+
+// createIndex create the index if it doesn't exist, conditions of the scope are used as the index's condition if it doesn't have one
+func (scope *Scope) createIndex(index Index) {
+	if scope.Dialect().HasIndex(scope.TableName(), index.Name) {
+		return
+	}
+
+	index.sortColumns()
+	sql, err := scope.Dialect().CreateIndexSQL(scope.QuotedTableName(), index)
+	if scope.Err(err) != nil {
+		return
+	}
+
+	if index.Where == "" {
+		sql = fmt.Sprintf("%v %v", sql, scope.whereSQL())
+	}
+	scope.planReason("create index `%v` on `%v`", index.Name, scope.TableName())
+	scope.Raw(sql).Exec()
+}
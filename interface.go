@@ -1,6 +1,9 @@
 package gorm
 
-import "database/sql"
+import (
+	"context"
+	"database/sql"
+)
 
 // SQLCommon is the minimal database connection functionality gorm requires.  Implemented by *sql.DB.
 type SQLCommon interface {
@@ -10,11 +13,44 @@ type SQLCommon interface {
 	QueryRow(query string, args ...interface{}) *sql.Row
 }
 
+// sqlCommonContext is implemented by connections supporting context, like *sql.DB, *sql.Tx
+type sqlCommonContext interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
 type sqlDb interface {
 	Begin() (*sql.Tx, error)
 }
 
+type sqlDbContext interface {
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
 type sqlTx interface {
 	Commit() error
 	Rollback() error
 }
+
+// beginTransaction start a transaction with the connection, use the context if supported, returns ErrCantStartTransaction if the connection can't start one
+func beginTransaction(db SQLCommon, ctx context.Context) (SQLCommon, error) {
+	var (
+		tx  *sql.Tx
+		err error
+	)
+
+	if beginner, ok := db.(sqlDbContext); ok && beginner != nil && ctx != nil {
+		tx, err = beginner.BeginTx(ctx, nil)
+	} else if beginner, ok := db.(sqlDb); ok && beginner != nil {
+		tx, err = beginner.Begin()
+	} else {
+		return nil, ErrCantStartTransaction
+	}
+
+	// cached statements are re-bound to the transaction
+	if stmtDB, ok := db.(*preparedStmtDB); ok && err == nil {
+		return &preparedStmtTx{Tx: tx, stmtDB: stmtDB}, nil
+	}
+	return tx, err
+}
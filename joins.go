@@ -0,0 +1,147 @@
+package gorm
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/jinzhu/gorm/clause"
+)
+
+// joinedColumnSeparator separate the association's name and the column's name in aliases of joined columns, e.g. `Company__name`
+const joinedColumnSeparator = "__"
+
+// associationJoin build the left join of the belongs_to or has_one association if the join's query is the association's field name, e.g. `db.Joins("Company")`,
+// the joined table is aliased as the field name, its columns are selected with aliases like `Company__name`
+func (scope *Scope) associationJoin(condition clause.Expr) (join clause.Join, columns []clause.Column, ok bool) {
+	name := condition.SQL
+	if len(condition.Vars) > 0 || name == "" {
+		return
+	}
+
+	var field *StructField
+	for _, structField := range scope.GetModelStruct().StructFields {
+		if structField.Name == name && structField.Relationship != nil {
+			field = structField
+			break
+		}
+	}
+
+	if field == nil || (field.Relationship.Kind != "belongs_to" && field.Relationship.Kind != "has_one") {
+		return
+	}
+
+	fieldType := field.Struct.Type
+	if fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+	if fieldType.Kind() != reflect.Struct {
+		return
+	}
+
+	var (
+		relationship = field.Relationship
+		joinScope    = scope.New(reflect.New(fieldType).Interface())
+	)
+
+	join = clause.Join{Type: clause.LeftJoin, Table: clause.Table{Name: joinScope.TableName(), Alias: name}}
+	for idx, foreignDBName := range relationship.ForeignDBNames {
+		associationDBName := relationship.AssociationForeignDBNames[idx]
+		if relationship.Kind == "belongs_to" {
+			// the model's foreign key refers to the association's key
+			join.ON = append(join.ON, clause.Eq{Column: clause.Column{Table: name, Name: associationDBName}, Value: clause.Column{Table: clause.CurrentTable, Name: foreignDBName}})
+		} else {
+			join.ON = append(join.ON, clause.Eq{Column: clause.Column{Table: name, Name: foreignDBName}, Value: clause.Column{Table: clause.CurrentTable, Name: associationDBName}})
+		}
+	}
+
+	if relationship.PolymorphicType != "" {
+		join.ON = append(join.ON, clause.Eq{Column: clause.Column{Table: name, Name: relationship.PolymorphicDBName}, Value: relationship.PolymorphicValue})
+	}
+
+	for _, joinField := range joinScope.GetModelStruct().StructFields {
+		if joinField.Name == "DeletedAt" && !scope.Search.Unscoped {
+			join.ON = append(join.ON, clause.Eq{Column: clause.Column{Table: name, Name: joinField.DBName}})
+		}
+
+		if joinField.IsNormal && !joinField.IsIgnored {
+			columns = append(columns, clause.Column{Table: name, Name: joinField.DBName, Alias: name + joinedColumnSeparator + joinField.DBName})
+		}
+	}
+	return join, columns, true
+}
+
+// joinedStruct the struct field of an association joined by name, scanned from columns with aliases like `Company__name`
+type joinedStruct struct {
+	field  *Field
+	value  reflect.Value
+	fields []*Field
+	// targets fields of the struct scanned from columns, keyed by indexes of columns
+	targets map[int]*Field
+}
+
+// joinedColumnTarget return the pointer to scan the column into if it is a column of an association joined by name, the struct of the association is added to joinedStructs
+func (scope *Scope) joinedColumnTarget(index int, column string, fields []*Field, joinedStructs map[string]*joinedStruct) (interface{}, bool) {
+	names := strings.SplitN(column, joinedColumnSeparator, 2)
+	if len(names) != 2 {
+		return nil, false
+	}
+
+	joined, ok := joinedStructs[names[0]]
+	if !ok {
+		for _, field := range fields {
+			if field.Name == names[0] && field.Relationship != nil {
+				fieldType := field.Struct.Type
+				if fieldType.Kind() == reflect.Ptr {
+					fieldType = fieldType.Elem()
+				}
+				if fieldType.Kind() != reflect.Struct {
+					break
+				}
+
+				value := reflect.New(fieldType)
+				joined = &joinedStruct{field: field, value: value, fields: scope.New(value.Interface()).Fields(), targets: map[int]*Field{}}
+				joinedStructs[names[0]] = joined
+				break
+			}
+		}
+
+		if joined == nil {
+			return nil, false
+		}
+	}
+
+	for _, field := range joined.fields {
+		if field.DBName == names[1] && field.IsNormal {
+			joined.targets[index] = field
+			// scan into a pointer to the field's type, so NULLs of unmatched rows are detected
+			if field.Field.Kind() == reflect.Ptr {
+				return reflect.New(field.Struct.Type).Interface(), true
+			}
+			return reflect.New(reflect.PtrTo(field.Struct.Type)).Interface(), true
+		}
+	}
+	return nil, false
+}
+
+// set set scanned values to the struct's fields, the association is left blank if all columns are NULL, which means no row matched the join
+func (joined *joinedStruct) set(values []interface{}) {
+	matched := false
+	for index, field := range joined.targets {
+		if value := reflect.ValueOf(values[index]).Elem(); !value.IsNil() {
+			matched = true
+			if field.Field.Kind() == reflect.Ptr {
+				field.Field.Set(value)
+			} else {
+				field.Field.Set(value.Elem())
+			}
+		}
+	}
+
+	if !matched {
+		joined.field.Field.Set(reflect.Zero(joined.field.Struct.Type))
+	} else if joined.field.Field.Kind() == reflect.Ptr {
+		joined.field.Field.Set(joined.value)
+	} else {
+		joined.field.Field.Set(joined.value.Elem())
+	}
+}
@@ -0,0 +1,70 @@
+package gorm
+
+import "github.com/jinzhu/gorm/clause"
+
+// LockingOption strength or option of row locking passed to `Locking`
+type LockingOption string
+
+// Locking strengths and options, a strength is required and at most one option could be used with it
+const (
+	// ForUpdate lock rows for updating, e.g. `FOR UPDATE`
+	ForUpdate LockingOption = clause.LockingStrengthUpdate
+	// ForShare lock rows in share mode, other transactions could read but not update them, e.g. `FOR SHARE`
+	ForShare LockingOption = clause.LockingStrengthShare
+	// NoWait fail immediately if rows are locked by other transactions
+	NoWait LockingOption = clause.LockingOptionsNoWait
+	// SkipLocked skip rows locked by other transactions, e.g. to take jobs from a queue
+	SkipLocked LockingOption = clause.LockingOptionsSkipLocked
+)
+
+// rawClause a clause written as is, e.g. locking sql rendered by the dialect
+type rawClause struct {
+	name string
+	sql  string
+}
+
+func (c rawClause) Name() string {
+	return c.name
+}
+
+func (c rawClause) Build(builder clause.Builder) {
+	builder.WriteString(c.sql)
+}
+
+// tableHintFrom the from clause with the table hint written after its tables and before its joins
+type tableHintFrom struct {
+	clause.From
+	hint string
+}
+
+func (from tableHintFrom) Build(builder clause.Builder) {
+	joins := from.Joins
+	from.Joins = nil
+	from.From.Build(builder)
+	builder.WriteString(" " + from.hint)
+
+	for _, join := range joins {
+		builder.WriteString(" ")
+		join.Build(builder)
+	}
+}
+
+// resolveLocking replace the locking clause with sql rendered by the dialect, table hints are added to the from clause
+func (scope *Scope) resolveLocking(clauses map[string]clause.Interface) {
+	locking, ok := clauses["FOR"].(clause.Locking)
+	if !ok || locking.Strength == "" {
+		return
+	}
+
+	delete(clauses, "FOR")
+	sql, tableHint, err := scope.Dialect().LockingSQL(locking.Strength, locking.Options)
+	if scope.Err(err) != nil || sql == "" {
+		return
+	}
+
+	if !tableHint {
+		clauses["FOR"] = rawClause{name: "FOR", sql: sql}
+	} else if from, ok := clauses["FROM"].(clause.From); ok {
+		clauses["FROM"] = tableHintFrom{From: from, hint: sql}
+	}
+}
@@ -2,17 +2,20 @@ package gorm
 
 import (
 	"database/sql/driver"
+	"encoding/json"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"reflect"
 	"regexp"
+	"sync"
 	"time"
 	"unicode"
 )
 
 var (
-	defaultLogger            = Logger{log.New(os.Stdout, "\r\n", 0)}
+	defaultLogger            = printLogger{Logger{log.New(os.Stdout, "\r\n", 0)}}
 	sqlRegexp                = regexp.MustCompile(`\?`)
 	numericPlaceHolderRegexp = regexp.MustCompile(`\$\d+`)
 )
@@ -27,20 +30,33 @@ func isPrintable(s string) bool {
 }
 
 var LogFormatter = func(values ...interface{}) (messages []interface{}) {
+	return formatLog(true, values...)
+}
+
+// formatLog format values of `Print` to messages, values are level, source, and duration, sql, vars, rows affected and the error if it failed for sql logs,
+// messages are highlighted with ANSI colour codes if colorful
+func formatLog(colorful bool, values ...interface{}) (messages []interface{}) {
+	colorize := func(code, str string) string {
+		if colorful {
+			return "\033[" + code + "m" + str + "\033[0m"
+		}
+		return str
+	}
+
 	if len(values) > 1 {
 		var (
 			sql             string
 			formattedValues []string
 			level           = values[0]
-			currentTime     = "\n\033[33m[" + NowFunc().Format("2006-01-02 15:04:05") + "]\033[0m"
-			source          = fmt.Sprintf("\033[35m(%v)\033[0m", values[1])
+			currentTime     = "\n" + colorize("33", "["+NowFunc().Format("2006-01-02 15:04:05")+"]")
+			source          = colorize("35", fmt.Sprintf("(%v)", values[1]))
 		)
 
 		messages = []interface{}{source, currentTime}
 
 		if level == "sql" {
 			// duration
-			messages = append(messages, fmt.Sprintf(" \033[36;1m[%.2fms]\033[0m ", float64(values[2].(time.Duration).Nanoseconds()/1e4)/100.0))
+			messages = append(messages, " "+colorize("36;1", fmt.Sprintf("[%.2fms]", float64(values[2].(time.Duration).Nanoseconds()/1e4)/100.0))+" ")
 			// sql
 
 			for _, value := range values[4].([]interface{}) {
@@ -87,10 +103,21 @@ var LogFormatter = func(values ...interface{}) (messages []interface{}) {
 			}
 
 			messages = append(messages, sql)
-		} else {
+
+			// rows affected
+			if len(values) > 5 {
+				messages = append(messages, " "+colorize("36;31", fmt.Sprintf("[%v rows affected or returned]", values[5])))
+			}
+
+			if len(values) > 6 {
+				messages = append(messages, " "+colorize("31;1", fmt.Sprintf("[error: %v]", values[6])))
+			}
+		} else if colorful {
 			messages = append(messages, "\033[31;1m")
 			messages = append(messages, values[2:]...)
 			messages = append(messages, "\033[0m")
+		} else {
+			messages = append(messages, values[2:]...)
 		}
 	}
 
@@ -101,6 +128,40 @@ type logger interface {
 	Print(v ...interface{})
 }
 
+// LogLevel log level of a db, logs less important than the level are discarded
+type LogLevel int
+
+const (
+	// LogSilent don't log anything
+	LogSilent LogLevel = iota + 1
+	// LogError log errors, the default level
+	LogError
+	// LogWarn log errors and warnings
+	LogWarn
+	// LogInfo log errors, warnings, infos and sql statements
+	LogInfo
+)
+
+// LeveledLogger logger interface with log levels, set it with `DB.SetLeveledLogger`, messages are formatted with `fmt.Sprintf` when data are given
+type LeveledLogger interface {
+	Info(msg string, data ...interface{})
+	Warn(msg string, data ...interface{})
+	Error(msg string, data ...interface{})
+	// Trace log a sql statement executed at begin, err is the error happened when executing it
+	Trace(begin time.Time, sql string, vars []interface{}, rowsAffected int64, err error)
+}
+
+// SlowQueryEvent a statement took longer than the slow threshold, see `DB.SetSlowThreshold`
+type SlowQueryEvent struct {
+	Duration     time.Duration
+	SQL          string
+	Vars         []interface{}
+	RowsAffected int64
+	// Caller file and line of the code performing the statement
+	Caller string
+	Table  string
+}
+
 // LogWriter log writer interface
 type LogWriter interface {
 	Println(v ...interface{})
@@ -115,3 +176,127 @@ type Logger struct {
 func (logger Logger) Print(values ...interface{}) {
 	logger.Println(LogFormatter(values...)...)
 }
+
+// NewLogger return a text logger that writes logs with the writer, without colours unless colorful, e.g:
+//     db.SetLeveledLogger(gorm.NewLogger(log.New(os.Stdout, "\r\n", 0), false))
+func NewLogger(writer LogWriter, colorful bool) LeveledLogger {
+	return printLogger{textLogger{LogWriter: writer, colorful: colorful}}
+}
+
+type textLogger struct {
+	LogWriter
+	colorful bool
+}
+
+func (logger textLogger) Print(values ...interface{}) {
+	logger.Println(formatLog(logger.colorful, values...)...)
+}
+
+// printLogger adapt loggers with `Print(v ...interface{})` like `Logger` and `*log.Logger` set with `DB.SetLogger` to `LeveledLogger`,
+// they receive values of the format `LogFormatter` expects
+type printLogger struct {
+	logger
+}
+
+func (logger printLogger) Info(msg string, data ...interface{}) {
+	logger.Print("log", fileWithLineNum(), formatMessage(msg, data...))
+}
+
+func (logger printLogger) Warn(msg string, data ...interface{}) {
+	logger.Print("warn", fileWithLineNum(), formatMessage(msg, data...))
+}
+
+func (logger printLogger) Error(msg string, data ...interface{}) {
+	logger.Print("error", fileWithLineNum(), formatMessage(msg, data...))
+}
+
+func (logger printLogger) Trace(begin time.Time, sql string, vars []interface{}, rowsAffected int64, err error) {
+	if err != nil {
+		logger.Print("sql", fileWithLineNum(), NowFunc().Sub(begin), sql, vars, rowsAffected, err)
+	} else {
+		logger.Print("sql", fileWithLineNum(), NowFunc().Sub(begin), sql, vars, rowsAffected)
+	}
+}
+
+// NewJSONLogger return a logger that writes a JSON object per line to the writer, e.g:
+//     {"time":"2018-01-02T15:04:05.000000007Z","level":"info","caller":"main.go:25","duration_ms":1.05,"sql":"SELECT * FROM \"users\" WHERE (name = ?)","vars":["jinzhu"],"rows_affected":1}
+func NewJSONLogger(writer io.Writer) LeveledLogger {
+	return &jsonLogger{writer: writer}
+}
+
+type jsonLogger struct {
+	writer io.Writer
+	mutex  sync.Mutex
+}
+
+type jsonLogEntry struct {
+	Time         time.Time     `json:"time"`
+	Level        string        `json:"level"`
+	Caller       string        `json:"caller,omitempty"`
+	Message      string        `json:"msg,omitempty"`
+	Duration     *float64      `json:"duration_ms,omitempty"`
+	SQL          string        `json:"sql,omitempty"`
+	Vars         []interface{} `json:"vars,omitempty"`
+	RowsAffected *int64        `json:"rows_affected,omitempty"`
+	Error        string        `json:"error,omitempty"`
+}
+
+func (logger *jsonLogger) Info(msg string, data ...interface{}) {
+	logger.write(jsonLogEntry{Level: "info", Message: formatMessage(msg, data...)})
+}
+
+func (logger *jsonLogger) Warn(msg string, data ...interface{}) {
+	logger.write(jsonLogEntry{Level: "warn", Message: formatMessage(msg, data...)})
+}
+
+func (logger *jsonLogger) Error(msg string, data ...interface{}) {
+	logger.write(jsonLogEntry{Level: "error", Message: formatMessage(msg, data...)})
+}
+
+func (logger *jsonLogger) Trace(begin time.Time, sql string, vars []interface{}, rowsAffected int64, err error) {
+	duration := float64(NowFunc().Sub(begin).Nanoseconds()/1e4) / 100.0
+	entry := jsonLogEntry{Level: "info", Duration: &duration, SQL: sql, RowsAffected: &rowsAffected}
+
+	for _, value := range vars {
+		switch v := ParseValueForDialect(value).(type) {
+		case nil, bool, int64, uint64, float64, string, time.Time:
+			entry.Vars = append(entry.Vars, v)
+		case []byte:
+			if str := string(v); isPrintable(str) {
+				entry.Vars = append(entry.Vars, str)
+			} else {
+				entry.Vars = append(entry.Vars, "<binary>")
+			}
+		default:
+			entry.Vars = append(entry.Vars, fmt.Sprint(v))
+		}
+	}
+
+	if err != nil {
+		entry.Level, entry.Error = "error", err.Error()
+	}
+	logger.write(entry)
+}
+
+func (logger *jsonLogger) write(entry jsonLogEntry) {
+	entry.Time, entry.Caller = NowFunc(), fileWithLineNum()
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		// vars like NaN can't be encoded
+		entry.Vars = nil
+		line, _ = json.Marshal(entry)
+	}
+
+	logger.mutex.Lock()
+	defer logger.mutex.Unlock()
+	logger.writer.Write(append(line, '\n'))
+}
+
+// formatMessage format the message with data like `fmt.Sprintf`, the message is returned as it is without data
+func formatMessage(msg string, data ...interface{}) string {
+	if len(data) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, data...)
+}
@@ -1,33 +1,47 @@
 package gorm
 
 import (
+	"context"
 	"database/sql"
 	"errors"
 	"fmt"
 	"reflect"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/jinzhu/gorm/clause"
 )
 
 // DB contains information for current db connection
 type DB struct {
+	sync.RWMutex
 	Value        interface{}
 	Error        error
 	RowsAffected int64
 
 	// single db
 	db                SQLCommon
+	ctx               context.Context
 	blockGlobalUpdate bool
-	logMode           int
-	logger            logger
+	logLevel          LogLevel
+	logger            LeveledLogger
+	slowThreshold     time.Duration
+	onSlowQuery       func(SlowQueryEvent)
 	search            *search
 	values            map[string]interface{}
+	// savePoints counter of savepoints created by nested transactions of current transaction, used to name them uniquely
+	savePoints *uint64
 
 	// global db
 	parent        *DB
 	callbacks     *Callback
 	dialect       Dialect
 	singularTable bool
+	resolver      *replicaResolver
+
+	disableForeignKeyConstraints bool
 }
 
 // Open initialize a new db connection, need to import driver first, e.g:
@@ -66,9 +80,12 @@ func Open(dialect string, args ...interface{}) (db *DB, err error) {
 	db = &DB{
 		db:        dbSQL,
 		logger:    defaultLogger,
+		logLevel:  LogError,
 		values:    map[string]interface{}{},
 		callbacks: DefaultCallback,
 		dialect:   newDialect(dialect, dbSQL),
+		// the connection could be a transaction, e.g. `*sql.Tx`
+		savePoints: new(uint64),
 	}
 	db.parent = db
 
@@ -89,12 +106,23 @@ func (s *DB) New() *DB {
 	return clone
 }
 
+// WithContext return a new db that performs its operations with the given context, cancelling the context aborts running queries
+//     db.WithContext(ctx).Find(&users)
+func (s *DB) WithContext(ctx context.Context) *DB {
+	clone := s.clone()
+	clone.ctx = ctx
+	return clone
+}
+
 type closer interface {
 	Close() error
 }
 
-// Close close current db connection.  If database connection is not an io.Closer, returns an error.
+// Close close current db connection and cached prepared statements, replica connections are owned by the caller and not closed.  If database connection is not an io.Closer, returns an error.
 func (s *DB) Close() error {
+	if stmtDB, ok := s.db.(*preparedStmtDB); ok {
+		stmtDB.closeStmts()
+	}
 	if db, ok := s.parent.db.(closer); ok {
 		return db.Close()
 	}
@@ -104,6 +132,10 @@ func (s *DB) Close() error {
 // DB get `*sql.DB` from current connection
 // If the underlying database connection is not a *sql.DB, returns nil
 func (s *DB) DB() *sql.DB {
+	if stmtDB, ok := s.db.(*preparedStmtDB); ok {
+		db, _ := stmtDB.db.(*sql.DB)
+		return db
+	}
 	db, _ := s.db.(*sql.DB)
 	return db
 }
@@ -126,21 +158,68 @@ func (s *DB) Callback() *Callback {
 	return s.parent.callbacks
 }
 
-// SetLogger replace default logger
+// SetLogger replace default logger with a logger with `Print(v ...interface{})` like `Logger` and `*log.Logger`, e.g:
+//     db.SetLogger(gorm.Logger{log.New(os.Stdout, "\r\n", 0)})
 func (s *DB) SetLogger(log logger) {
+	s.logger = printLogger{log}
+}
+
+// SetLeveledLogger replace default logger with a logger with log levels, e.g:
+//     db.SetLeveledLogger(gorm.NewJSONLogger(os.Stdout))
+func (s *DB) SetLeveledLogger(log LeveledLogger) {
 	s.logger = log
 }
 
 // LogMode set log mode, `true` for detailed logs, `false` for no log, default, will only print error logs
 func (s *DB) LogMode(enable bool) *DB {
 	if enable {
-		s.logMode = 2
-	} else {
-		s.logMode = 1
+		return s.SetLogLevel(LogInfo)
+	}
+	return s.SetLogLevel(LogSilent)
+}
+
+// SetLogLevel set log level, logs less important than the level are discarded, default level is `LogError`
+//     db.SetLogLevel(gorm.LogWarn)
+func (s *DB) SetLogLevel(level LogLevel) *DB {
+	s.logLevel = level
+	return s
+}
+
+// PrepareStmt if true, SQL statements will be prepared and cached, cached statements are reused by later executions of the same SQL,
+// least recently used statements will be closed when the cache is full, default cache size is 200
+//     db.PrepareStmt(true, 500)
+func (s *DB) PrepareStmt(enable bool, cacheSize ...int) *DB {
+	stmtDB, prepared := s.db.(*preparedStmtDB)
+	if _, inTransaction := s.db.(sqlTx); enable && !prepared && !inTransaction {
+		var size int
+		if len(cacheSize) > 0 {
+			size = cacheSize[0]
+		}
+		s.db = newPreparedStmtDB(s.db, size)
+	} else if !enable && prepared {
+		stmtDB.closeStmts()
+		s.db = stmtDB.db
 	}
 	return s
 }
 
+// SetSlowThreshold report statements that take longer than the threshold as slow queries, they are logged as warnings,
+// or sent to the hook set with `OnSlowQuery`, 0 disables reporting
+//     db.SetSlowThreshold(200 * time.Millisecond).SetLogLevel(gorm.LogWarn)
+func (s *DB) SetSlowThreshold(threshold time.Duration) *DB {
+	s.slowThreshold = threshold
+	return s
+}
+
+// OnSlowQuery set the hook to handle slow queries instead of logging them, slow queries are reported with `SetSlowThreshold`
+//     db.SetSlowThreshold(time.Second).OnSlowQuery(func(event gorm.SlowQueryEvent) {
+//       alert(event.Table, event.SQL, event.Duration)
+//     })
+func (s *DB) OnSlowQuery(hook func(event SlowQueryEvent)) *DB {
+	s.onSlowQuery = hook
+	return s
+}
+
 // BlockGlobalUpdate if true, generates an error on update/delete without where clause.
 // This is to prevent eventual error with empty objects updates/deletions
 func (s *DB) BlockGlobalUpdate(enable bool) *DB {
@@ -155,6 +234,8 @@ func (s *DB) HasBlockGlobalUpdate() bool {
 
 // SingularTable use singular table by default
 func (s *DB) SingularTable(enable bool) {
+	s.parent.Lock()
+	defer s.parent.Unlock()
 	modelStructsMap = newModelStructsMap()
 	s.parent.singularTable = enable
 }
@@ -166,7 +247,8 @@ func (s *DB) NewScope(value interface{}) *Scope {
 	return &Scope{db: dbClone, Search: dbClone.search.clone(), Value: value}
 }
 
-// Where return a new relation, filter records with given conditions, accepts `map`, `struct` or `string` as conditions, refer http://jinzhu.github.io/gorm/crud.html#query
+// Where return a new relation, filter records with given conditions, accepts `map`, `struct`, `string` or clause expressions as conditions, refer http://jinzhu.github.io/gorm/crud.html#query
+//     db.Where(clause.Gt{Column: "age", Value: 18}).Find(&users)
 func (s *DB) Where(query interface{}, args ...interface{}) *DB {
 	return s.clone().search.Where(query, args...).db
 }
@@ -220,8 +302,10 @@ func (s *DB) Having(query string, values ...interface{}) *DB {
 	return s.clone().search.Having(query, values...).db
 }
 
-// Joins specify Joins conditions
+// Joins specify Joins conditions, belongs_to and has_one associations could be joined by their field names,
+// which are loaded into the association fields in the same query, nil pointers of associations mean no rows matched
 //     db.Joins("JOIN emails ON emails.user_id = users.id AND emails.email = ?", "jinzhu@example.org").Find(&user)
+//     db.Joins("Company").Find(&users)
 func (s *DB) Joins(query string, args ...interface{}) *DB {
 	return s.clone().search.Joins(query, args...).db
 }
@@ -251,6 +335,58 @@ func (s *DB) Unscoped() *DB {
 	return s.clone().search.unscoped().db
 }
 
+// Clauses add clauses to current operation, e.g. read from the primary connection when replicas are registered, or resolve conflicts when creating records,
+// clauses of the `clause` package are merged into clauses populated by callbacks before rendering, they can't be used with raw sql of `Raw` and `Exec`
+//     db.Clauses(gorm.UsePrimary).Find(&users)
+//     db.Clauses(gorm.OnConflict{Columns: []string{"email"}, DoNothing: true}).Create(&users)
+//     db.Clauses(clause.Where{Exprs: []clause.Expression{clause.Gt{Column: "age", Value: 18}}}).Find(&users)
+func (s *DB) Clauses(clauses ...interface{}) *DB {
+	clone := s.clone()
+	for _, value := range clauses {
+		switch value := value.(type) {
+		case usePrimary:
+			clone.InstantSet("gorm:use_primary", true)
+		case OnConflict, *OnConflict:
+			clone.InstantSet("gorm:on_conflict", value)
+		case clause.Interface:
+			clone.search.clauses = append(clone.search.clauses, value)
+		default:
+			clone.AddError(ErrUnsupportedClause)
+		}
+	}
+	return clone
+}
+
+// Locking lock rows selected by queries with a strength and an option, the dialect renders it in its position, e.g. mssql uses table hints and sqlite doesn't lock rows,
+// `ErrUnsupportedLocking` happens if there isn't a strength, or current dialect doesn't support the combination
+//     db.Locking(gorm.ForUpdate, gorm.SkipLocked).Where("state = ?", "pending").First(&job)
+func (s *DB) Locking(options ...LockingOption) *DB {
+	var (
+		locking clause.Locking
+		valid   = true
+	)
+
+	for _, option := range options {
+		switch option {
+		case ForUpdate, ForShare:
+			valid = valid && locking.Strength == ""
+			locking.Strength = string(option)
+		case NoWait, SkipLocked:
+			valid = valid && locking.Options == ""
+			locking.Options = string(option)
+		default:
+			valid = false
+		}
+	}
+
+	if !valid || locking.Strength == "" {
+		clone := s.clone()
+		clone.AddError(ErrUnsupportedLocking)
+		return clone
+	}
+	return s.Clauses(locking)
+}
+
 // Attrs initialize struct with argument if record not found with `FirstOrInit` https://jinzhu.github.io/gorm/crud.html#firstorinit or `FirstOrCreate` https://jinzhu.github.io/gorm/crud.html#firstorcreate
 func (s *DB) Attrs(attrs ...interface{}) *DB {
 	return s.clone().search.Attrs(attrs...).db
@@ -373,6 +509,8 @@ func (s *DB) Updates(values interface{}, ignoreProtectedAttrs ...bool) *DB {
 }
 
 // UpdateColumn update attributes without callbacks, refer: https://jinzhu.github.io/gorm/crud.html#update
+// The optimistic lock version is still checked and increased, disable it with `gorm:optimistic_lock`
+//     db.Set("gorm:optimistic_lock", false).Model(&product).UpdateColumn("price", 100)
 func (s *DB) UpdateColumn(attrs ...interface{}) *DB {
 	return s.UpdateColumns(toSearchableMap(attrs...))
 }
@@ -391,7 +529,7 @@ func (s *DB) Save(value interface{}) *DB {
 	scope := s.clone().NewScope(value)
 	if !scope.PrimaryKeyZero() {
 		newDB := scope.callCallbacks(s.parent.callbacks.updates).db
-		if newDB.Error == nil && newDB.RowsAffected == 0 {
+		if newDB.Error == nil && newDB.RowsAffected == 0 && !scope.isDryRun() {
 			return s.New().FirstOrCreate(value)
 		}
 		return newDB
@@ -419,9 +557,10 @@ func (s *DB) Raw(sql string, values ...interface{}) *DB {
 // Exec execute raw sql
 func (s *DB) Exec(sql string, values ...interface{}) *DB {
 	scope := s.clone().NewScope(nil)
-	generatedSQL := scope.buildWhereCondition(map[string]interface{}{"query": sql, "args": values})
-	generatedSQL = strings.TrimSuffix(strings.TrimPrefix(generatedSQL, "("), ")")
-	scope.Raw(generatedSQL)
+	if len(scope.Search.clauses) > 0 {
+		scope.Err(ErrUnsupportedClause)
+	}
+	scope.Raw(scope.buildExpr(namedExpr(sql, values)))
 	return scope.Exec().db
 }
 
@@ -436,14 +575,39 @@ func (s *DB) Model(value interface{}) *DB {
 	return c
 }
 
-// Table specify the table you would like to run db operations
-func (s *DB) Table(name string) *DB {
+// Table specify the table you would like to run db operations, it could be a subquery with an alias, e.g:
+//     db.Table("(?) AS u", db.Table("users").Select("name, age")).Where("u.age > ?", 18).Find(&users)
+func (s *DB) Table(name string, args ...interface{}) *DB {
 	clone := s.clone()
-	clone.search.Table(name)
+	clone.search.Table(name, args...)
 	clone.Value = nil
 	return clone
 }
 
+// DryRun return a new db that builds statements without executing them, transactions won't be started either,
+// the statement of last operation could be got with `Get("gorm:dry_run_sql")` and `Get("gorm:dry_run_vars")`
+func (s *DB) DryRun() *DB {
+	return s.Set("gorm:dry_run", true)
+}
+
+// ToSQL return the statement of the operation in dry run mode, with vars interpolated by the dialect
+//     sql := db.ToSQL(func(tx *gorm.DB) *gorm.DB {
+//       return tx.Where("name = ?", "jinzhu").Find(&users)
+//     })
+//     // SELECT * FROM "users" WHERE (name = 'jinzhu')
+func (s *DB) ToSQL(queryFn func(tx *DB) *DB) string {
+	tx := queryFn(s.DryRun())
+	if tx == nil {
+		return ""
+	}
+
+	sql, _ := tx.Get("gorm:dry_run_sql")
+	vars, _ := tx.Get("gorm:dry_run_vars")
+	sqlString, _ := sql.(string)
+	sqlVars, _ := vars.([]interface{})
+	return interpolateVars(s.Dialect(), sqlString, sqlVars)
+}
+
 // Debug start debug mode
 func (s *DB) Debug() *DB {
 	return s.clone().LogMode(true)
@@ -452,13 +616,11 @@ func (s *DB) Debug() *DB {
 // Begin begin a transaction
 func (s *DB) Begin() *DB {
 	c := s.clone()
-	if db, ok := c.db.(sqlDb); ok && db != nil {
-		tx, err := db.Begin()
-		c.db = interface{}(tx).(SQLCommon)
-		c.AddError(err)
-	} else {
-		c.AddError(ErrCantStartTransaction)
+	tx, err := beginTransaction(c.db, c.ctx)
+	if tx != nil {
+		c.db, c.savePoints = tx, new(uint64)
 	}
+	c.AddError(err)
 	return c
 }
 
@@ -482,6 +644,74 @@ func (s *DB) Rollback() *DB {
 	return s
 }
 
+// SavePoint create a savepoint with the given name inside current transaction
+func (s *DB) SavePoint(name string) *DB {
+	if _, ok := s.db.(sqlTx); ok {
+		_, err := s.NewScope(nil).sqlExec(s.Dialect().SavePointSQL(name))
+		s.AddError(err)
+	} else {
+		s.AddError(ErrInvalidTransaction)
+	}
+	return s
+}
+
+// RollbackTo rollback current transaction to the savepoint with the given name
+func (s *DB) RollbackTo(name string) *DB {
+	if _, ok := s.db.(sqlTx); ok {
+		_, err := s.NewScope(nil).sqlExec(s.Dialect().RollbackToSavePointSQL(name))
+		s.AddError(err)
+	} else {
+		s.AddError(ErrInvalidTransaction)
+	}
+	return s
+}
+
+// Transaction run the given function in a transaction, commit it if the function returns nil, rollback it if the function returns an error or panics.
+// Calling it on a db which is already in a transaction will use a savepoint, so only changes made by the nested function are rolled back
+//     db.Transaction(func(tx *gorm.DB) error {
+//       if err := tx.Create(&user).Error; err != nil {
+//         return err
+//       }
+//       return tx.Create(&order).Error
+//     })
+func (s *DB) Transaction(fc func(tx *DB) error) (err error) {
+	var (
+		tx        *DB
+		panicked  = true
+		_, nested = s.db.(sqlTx)
+	)
+
+	if nested {
+		// savepoints with the same name replace previous ones, so they are numbered in the transaction
+		savePoint := fmt.Sprintf("sp%v", atomic.AddUint64(s.savePoints, 1))
+		if tx = s.clone().SavePoint(savePoint); tx.Error != nil {
+			return tx.Error
+		}
+
+		defer func() {
+			if panicked || err != nil {
+				tx.RollbackTo(savePoint)
+			}
+		}()
+	} else {
+		if tx = s.Begin(); tx.Error != nil {
+			return tx.Error
+		}
+
+		defer func() {
+			if panicked || err != nil {
+				tx.Rollback()
+			}
+		}()
+	}
+
+	if err = fc(tx); err == nil && !nested {
+		err = tx.Commit().Error
+	}
+	panicked = false
+	return
+}
+
 // NewRecord check if value's primary key is blank
 func (s *DB) NewRecord(value interface{}) bool {
 	return s.clone().NewScope(value).PrimaryKeyZero()
@@ -500,10 +730,11 @@ func (s *DB) RecordNotFound() bool {
 // CreateTable create table for models
 func (s *DB) CreateTable(models ...interface{}) *DB {
 	db := s.Unscoped()
+	constraints := db.foreignKeyConstraints(models...)
 	for _, model := range models {
-		db = db.NewScope(model).createTable().db
+		db = db.NewScope(model).createTable(constraints).db
 	}
-	return db
+	return db.NewScope(nil).addForeignKeyConstraints(constraints).db
 }
 
 // DropTable drop table for models
@@ -551,10 +782,11 @@ func (s *DB) HasTable(value interface{}) bool {
 // AutoMigrate run auto migration for given models, will only add missing fields, won't delete/change current data
 func (s *DB) AutoMigrate(values ...interface{}) *DB {
 	db := s.Unscoped()
+	constraints := db.foreignKeyConstraints(values...)
 	for _, value := range values {
-		db = db.NewScope(value).autoMigrate().db
+		db = db.NewScope(value).autoMigrate(constraints).db
 	}
-	return db
+	return db.NewScope(nil).addForeignKeyConstraints(constraints).db
 }
 
 // ModifyColumn modify column to type
@@ -571,6 +803,22 @@ func (s *DB) DropColumn(column string) *DB {
 	return scope.db
 }
 
+// RenameColumn rename a column, its definition and data are kept, e.g:
+//     db.Model(&User{}).RenameColumn("name", "full_name")
+func (s *DB) RenameColumn(oldName string, newName string) *DB {
+	scope := s.clone().NewScope(s.Value)
+	scope.renameColumn(oldName, newName)
+	return scope.db
+}
+
+// RenameTable rename a table, e.g:
+//     db.RenameTable("users", "people")
+func (s *DB) RenameTable(oldName string, newName string) *DB {
+	scope := s.clone().NewScope(s.Value)
+	scope.Raw(scope.Dialect().RenameTableSQL(oldName, newName)).Exec()
+	return scope.db
+}
+
 // AddIndex add index for columns with given name
 func (s *DB) AddIndex(indexName string, columns ...string) *DB {
 	scope := s.Unscoped().NewScope(s.Value)
@@ -592,6 +840,22 @@ func (s *DB) RemoveIndex(indexName string) *DB {
 	return scope.db
 }
 
+// RenameIndex rename index with given name, e.g:
+//     db.Model(&User{}).RenameIndex("idx_users_name", "idx_users_full_name")
+func (s *DB) RenameIndex(oldName string, newName string) *DB {
+	scope := s.clone().NewScope(s.Value)
+	scope.renameIndex(oldName, newName)
+	return scope.db
+}
+
+// DropConstraint drop constraint with name, e.g:
+//     db.Model(&User{}).DropConstraint("chk_users_age")
+func (s *DB) DropConstraint(constraintName string) *DB {
+	scope := s.clone().NewScope(s.Value)
+	scope.dropConstraint(constraintName)
+	return scope.db
+}
+
 // AddForeignKey Add foreign key to the given scope, e.g:
 //     db.Model(&User{}).AddForeignKey("city_id", "cities(id)", "RESTRICT", "RESTRICT")
 func (s *DB) AddForeignKey(field string, dest string, onDelete string, onUpdate string) *DB {
@@ -667,10 +931,8 @@ func (s *DB) SetJoinTableHandler(source interface{}, column string, handler Join
 func (s *DB) AddError(err error) error {
 	if err != nil {
 		if err != ErrRecordNotFound {
-			if s.logMode == 0 {
-				go s.print(fileWithLineNum(), err)
-			} else {
-				s.log(err)
+			if s.logLevel >= LogError {
+				s.logger.Error("%v", err)
 			}
 
 			errors := Errors(s.GetErrors())
@@ -702,13 +964,17 @@ func (s *DB) GetErrors() []error {
 func (s *DB) clone() *DB {
 	db := DB{
 		db:                s.db,
+		ctx:               s.ctx,
 		parent:            s.parent,
 		logger:            s.logger,
-		logMode:           s.logMode,
+		logLevel:          s.logLevel,
+		slowThreshold:     s.slowThreshold,
+		onSlowQuery:       s.onSlowQuery,
 		values:            map[string]interface{}{},
 		Value:             s.Value,
 		Error:             s.Error,
 		blockGlobalUpdate: s.blockGlobalUpdate,
+		savePoints:        s.savePoints,
 	}
 
 	for key, value := range s.values {
@@ -725,18 +991,29 @@ func (s *DB) clone() *DB {
 	return &db
 }
 
-func (s *DB) print(v ...interface{}) {
-	s.logger.Print(v...)
+func (s *DB) log(v ...interface{}) {
+	if s != nil && s.logLevel >= LogInfo {
+		s.logger.Info(strings.TrimSuffix(fmt.Sprintln(v...), "\n"))
+	}
+}
+
+func (s *DB) slog(sql string, t time.Time, rowsAffected int64, err error, vars ...interface{}) {
+	if s.logLevel >= LogInfo {
+		s.logger.Trace(t, sql, vars, rowsAffected, err)
+	}
 }
 
-func (s *DB) log(v ...interface{}) {
-	if s != nil && s.logMode == 2 {
-		s.print(append([]interface{}{"log", fileWithLineNum()}, v...)...)
+func (s *DB) warn(msg string, data ...interface{}) {
+	if s.logLevel >= LogWarn {
+		s.logger.Warn(msg, data...)
 	}
 }
 
-func (s *DB) slog(sql string, t time.Time, vars ...interface{}) {
-	if s.logMode == 2 {
-		s.print("sql", fileWithLineNum(), NowFunc().Sub(t), sql, vars)
+func (s *DB) reportSlowQuery(event SlowQueryEvent) {
+	if s.onSlowQuery != nil {
+		s.onSlowQuery(event)
+	} else {
+		s.warn("slow query on `%v` took %v, exceeding %v, %v rows affected or returned, called from %v: %v",
+			event.Table, event.Duration, s.slowThreshold, event.RowsAffected, event.Caller, event.SQL)
 	}
 }
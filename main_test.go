@@ -1,13 +1,18 @@
 package gorm_test
 
 import (
+	"bytes"
+	"context"
 	"database/sql"
 	"database/sql/driver"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"reflect"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -381,6 +386,151 @@ func TestTransaction(t *testing.T) {
 	}
 }
 
+func TestTransactionWithBlock(t *testing.T) {
+	// rollback
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		u := User{Name: "transcation-block"}
+		if err := tx.Save(&u).Error; err != nil {
+			t.Errorf("No error should raise")
+		}
+
+		if err := tx.First(&User{}, "name = ?", u.Name).Error; err != nil {
+			t.Errorf("Should find saved record")
+		}
+
+		return errors.New("the error message")
+	})
+
+	if err.Error() != "the error message" {
+		t.Errorf("Transaction return error will equal the block returns error")
+	}
+
+	if err := DB.First(&User{}, "name = ?", "transcation-block").Error; err == nil {
+		t.Errorf("Should not find record after rollback")
+	}
+
+	// commit
+	DB.Transaction(func(tx *gorm.DB) error {
+		u2 := User{Name: "transcation-block-2"}
+		if err := tx.Save(&u2).Error; err != nil {
+			t.Errorf("No error should raise")
+		}
+
+		if err := tx.First(&User{}, "name = ?", u2.Name).Error; err != nil {
+			t.Errorf("Should find saved record")
+		}
+		return nil
+	})
+
+	if err := DB.First(&User{}, "name = ?", "transcation-block-2").Error; err != nil {
+		t.Errorf("Should be able to find committed record")
+	}
+
+	// panic will rollback and re-panic
+	func() {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Errorf("Transaction should re-panic after rollback")
+			}
+		}()
+
+		DB.Transaction(func(tx *gorm.DB) error {
+			u3 := User{Name: "transcation-block-3"}
+			if err := tx.Save(&u3).Error; err != nil {
+				t.Errorf("No error should raise")
+			}
+			panic("force panic")
+		})
+	}()
+
+	if err := DB.First(&User{}, "name = ?", "transcation-block-3").Error; err == nil {
+		t.Errorf("Should not find record after panic rollback")
+	}
+}
+
+func TestNestedTransactionWithBlock(t *testing.T) {
+	err := DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&User{Name: "transcation-nested"}).Error; err != nil {
+			t.Errorf("No error should raise")
+		}
+
+		if err := tx.Transaction(func(tx1 *gorm.DB) error {
+			tx1.Save(&User{Name: "transcation-nested-1"})
+			return errors.New("rollback")
+		}); err == nil {
+			t.Errorf("Nested transaction should return the block error")
+		}
+
+		if err := tx.First(&User{}, "name = ?", "transcation-nested-1").Error; err == nil {
+			t.Errorf("Should not find record rolled back to savepoint")
+		}
+
+		if err := tx.Transaction(func(tx2 *gorm.DB) error {
+			return tx2.Save(&User{Name: "transcation-nested-2"}).Error
+		}); err != nil {
+			t.Errorf("No error should raise, but got %v", err)
+		}
+
+		if err := tx.First(&User{}, "name = ?", "transcation-nested-2").Error; err != nil {
+			t.Errorf("Should find record saved in nested transaction")
+		}
+		return nil
+	})
+
+	if err != nil {
+		t.Errorf("No error should raise, but got %v", err)
+	}
+
+	if err := DB.First(&User{}, "name = ?", "transcation-nested").Error; err != nil {
+		t.Errorf("Should find record committed by outer transaction")
+	}
+
+	if err := DB.First(&User{}, "name = ?", "transcation-nested-1").Error; err == nil {
+		t.Errorf("Should not find record rolled back to savepoint")
+	}
+
+	if err := DB.First(&User{}, "name = ?", "transcation-nested-2").Error; err != nil {
+		t.Errorf("Should find record committed by outer transaction")
+	}
+
+	DB.Transaction(func(tx *gorm.DB) error {
+		tx.Save(&User{Name: "transcation-nested-3"})
+		return tx.Transaction(func(tx1 *gorm.DB) error {
+			tx1.Save(&User{Name: "transcation-nested-4"})
+			return errors.New("rollback all")
+		})
+	})
+
+	if err := DB.First(&User{}, "name = ? OR name = ?", "transcation-nested-3", "transcation-nested-4").Error; err == nil {
+		t.Errorf("Should not find records when outer transaction rolled back")
+	}
+
+	// the same function nested in itself, the outer savepoint should be rolled back to, not the inner one
+	var (
+		depth int
+		fc    func(tx *gorm.DB) error
+	)
+	fc = func(tx *gorm.DB) error {
+		depth++
+		tx.Save(&User{Name: fmt.Sprintf("transcation-nested-depth-%v", depth)})
+		if depth < 2 {
+			tx.Transaction(fc)
+		}
+		return errors.New("rollback")
+	}
+	DB.Transaction(func(tx *gorm.DB) error {
+		tx.Transaction(fc)
+		return nil
+	})
+
+	if err := DB.First(&User{}, "name LIKE ?", "transcation-nested-depth-%").Error; err == nil {
+		t.Errorf("Should rollback to savepoints of the same function in order")
+	}
+
+	// later tests like TestOffset expect a limited number of users
+	DB.Unscoped().Where("name LIKE ?", "transcation-nested%").Delete(&User{})
+}
+
 func TestRow(t *testing.T) {
 	user1 := User{Name: "RowUser1", Age: 1, Birthday: parseTime("2000-1-1")}
 	user2 := User{Name: "RowUser2", Age: 10, Birthday: parseTime("2010-1-1")}
@@ -585,6 +735,51 @@ func TestJoinsWithSelect(t *testing.T) {
 	}
 }
 
+type JoinCompany struct {
+	Id   int64
+	Name string
+}
+
+type JoinEmployee struct {
+	Id        int64
+	Name      string
+	CompanyID *int64
+	Company   *JoinCompany
+}
+
+func TestJoinsAssociation(t *testing.T) {
+	user := User{Name: "joins_association", Company: Company{Name: "joins_company"}, CreditCard: CreditCard{Number: "433333333333"}}
+	DB.Save(&user)
+
+	var users []User
+	if err := DB.Joins("Company").Joins("CreditCard").Where("users.name = ?", "joins_association").Find(&users).Error; err != nil {
+		t.Errorf("No error should happen when joining associations by name, but got %v", err)
+	}
+	if len(users) != 1 || users[0].Company.Name != "joins_company" || users[0].Company.Id != user.Company.Id || users[0].CreditCard.Number != "433333333333" {
+		t.Errorf("Should fill belongs_to and has_one associations from joined columns, but got %+v", users)
+	}
+
+	var count int
+	if DB.Model(&User{}).Joins("Company").Where("Company.name = ?", "joins_company").Count(&count); count != 1 {
+		t.Errorf("Should count users joined with their companies, but got %v", count)
+	}
+	DB.Unscoped().Delete(&user)
+
+	DB.DropTableIfExists(&JoinCompany{}, &JoinEmployee{})
+	DB.AutoMigrate(&JoinCompany{}, &JoinEmployee{})
+	DB.Save(&JoinEmployee{Name: "with_company", Company: &JoinCompany{Name: "join_company"}})
+	DB.Save(&JoinEmployee{Name: "without_company"})
+
+	var employees []JoinEmployee
+	DB.Joins("Company").Order("join_employees.id").Find(&employees)
+	if len(employees) != 2 || employees[0].Company == nil || employees[0].Company.Name != "join_company" {
+		t.Errorf("Should fill the joined association, but got %+v", employees)
+	}
+	if len(employees) == 2 && employees[1].Company != nil {
+		t.Errorf("The joined association should be nil if no row matched, but got %+v", employees[1].Company)
+	}
+}
+
 func TestHaving(t *testing.T) {
 	rows, err := DB.Select("name, count(*) as total").Table("users").Group("name").Having("name IN (?)", []string{"2", "3"}).Rows()
 
@@ -750,6 +945,355 @@ func TestOpenExistingDB(t *testing.T) {
 	}
 }
 
+func TestPrepareStmt(t *testing.T) {
+	db, err := OpenTestConnection()
+	if err != nil {
+		t.Fatalf("Failed to open test connection, got %v", err)
+	}
+	db.PrepareStmt(true, 2)
+
+	if _, ok := db.CommonDB().(*sql.DB); ok || db.DB() == nil {
+		t.Errorf("Connection should be wrapped to cache prepared statements")
+	}
+
+	for i := 0; i < 3; i++ {
+		toy := Toy{Name: fmt.Sprintf("PrepareStmtToy%v", i), OwnerId: i}
+		if err := db.Create(&toy).Error; err != nil {
+			t.Errorf("No error should happen when creating with prepared statements, but got %v", err)
+		}
+
+		var result Toy
+		if err := db.Where("name = ?", toy.Name).First(&result).Error; err != nil || result.Id != toy.Id {
+			t.Errorf("Should find created record with prepared statements, but got %v", err)
+		}
+
+		if err := db.Model(&result).Where("owner_id = ?", i).Update("owner_id", i+10).Error; err != nil {
+			t.Errorf("No error should happen when updating with prepared statements, but got %v", err)
+		}
+	}
+
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&Toy{Name: "PrepareStmtToy3"}).Error; err != nil {
+			t.Errorf("No error should happen when creating in transaction, but got %v", err)
+		}
+
+		if err := tx.Where("name = ?", "PrepareStmtToy3").First(&Toy{}).Error; err != nil {
+			t.Errorf("Should find record created in transaction, but got %v", err)
+		}
+		return errors.New("rollback")
+	}); err == nil {
+		t.Errorf("Transaction should return the error")
+	}
+
+	if !db.Where("name = ?", "PrepareStmtToy3").First(&Toy{}).RecordNotFound() {
+		t.Errorf("Should not find record after rollback")
+	}
+
+	var count int
+	if db.Model(&Toy{}).Where("name LIKE ?", "PrepareStmtToy%").Where("owner_id >= ?", 10).Count(&count); count != 3 {
+		t.Errorf("Should find updated records, but got %v", count)
+	}
+
+	if db.PrepareStmt(false); db.CommonDB() != db.DB() {
+		t.Errorf("Connection should be unwrapped after disabling prepared statements")
+	}
+
+	db.PrepareStmt(true)
+	if err := db.Close(); err != nil {
+		t.Errorf("No error should happen when closing connection, but got %v", err)
+	}
+
+	if err := db.First(&Toy{}).Error; err == nil {
+		t.Errorf("Should get error after connection closed")
+	}
+}
+
+type replicaConn struct {
+	gorm.SQLCommon
+	queries int
+}
+
+func (conn *replicaConn) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	conn.queries++
+	return conn.SQLCommon.Query(query, args...)
+}
+
+func (conn *replicaConn) QueryRow(query string, args ...interface{}) *sql.Row {
+	conn.queries++
+	return conn.SQLCommon.QueryRow(query, args...)
+}
+
+func TestReplicas(t *testing.T) {
+	db, err := OpenTestConnection()
+	if err != nil {
+		t.Fatalf("Failed to open test connection, got %v", err)
+	}
+	defer db.Close()
+
+	replica1, replica2 := &replicaConn{SQLCommon: db.DB()}, &replicaConn{SQLCommon: db.DB()}
+	db.UseReplicas(&gorm.RoundRobinPolicy{}, replica1, replica2)
+
+	toy := Toy{Name: "ReplicaToy"}
+	if db.Create(&toy); replica1.queries+replica2.queries != 0 {
+		t.Errorf("Should create records with the primary connection")
+	}
+
+	var result Toy
+	if db.First(&result, toy.Id); result.Name != toy.Name || replica1.queries != 1 {
+		t.Errorf("Should query with the first replica, but got %v, %v", result, replica1.queries)
+	}
+
+	var count int
+	if db.Model(&Toy{}).Where("name = ?", toy.Name).Count(&count); count != 1 || replica2.queries != 1 {
+		t.Errorf("Should count with the second replica, but got %v, %v", count, replica2.queries)
+	}
+
+	db.Model(&result).Update("name", "ReplicaToyNew")
+	db.Exec("UPDATE toys SET owner_id = ? WHERE id = ?", 1, toy.Id)
+	db.Clauses(gorm.UsePrimary).First(&result, toy.Id)
+	db.Transaction(func(tx *gorm.DB) error {
+		return tx.First(&Toy{}, toy.Id).Error
+	})
+	if replica1.queries != 1 || replica2.queries != 1 {
+		t.Errorf("Should use the primary connection for writes, transactions and UsePrimary, but got %v, %v", replica1.queries, replica2.queries)
+	}
+
+	if db.Find(&[]Toy{}); replica1.queries != 2 {
+		t.Errorf("Should query with the replicas in turn")
+	}
+
+	if err := db.Clauses("unknown").First(&Toy{}).Error; err != gorm.ErrUnsupportedClause {
+		t.Errorf("Should get error with unsupported clause, but got %v", err)
+	}
+}
+
+func TestCloseWithReplicas(t *testing.T) {
+	db, err := OpenTestConnection()
+	if err != nil {
+		t.Fatalf("Failed to open test connection, got %v", err)
+	}
+
+	replica, err := OpenTestConnection()
+	if err != nil {
+		t.Fatalf("Failed to open test connection, got %v", err)
+	}
+	defer replica.Close()
+
+	db.UseReplicas(nil, replica.DB())
+	if err := db.Close(); err != nil {
+		t.Errorf("No error should happen when closing db, but got %v", err)
+	}
+
+	if err := replica.DB().Ping(); err != nil {
+		t.Errorf("Should not close replicas owned by the caller, but got %v", err)
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	dryRunDB := DB.DryRun()
+
+	toy := Toy{Name: "DryRunToy"}
+	if err := dryRunDB.Create(&toy).Error; err != nil || toy.Id != 0 {
+		t.Errorf("Should not insert record in dry run mode, but got %v, %+v", err, toy)
+	}
+
+	if sql, ok := dryRunDB.Create(&toy).Get("gorm:dry_run_sql"); !ok || !strings.HasPrefix(fmt.Sprint(sql), "INSERT INTO") {
+		t.Errorf("Should keep the built statement in dry run mode, but got %v", sql)
+	}
+
+	if err := dryRunDB.Where("name = ?", toy.Name).First(&toy).Error; err != nil {
+		t.Errorf("Should not get record not found error in dry run mode, but got %v", err)
+	}
+
+	var count int
+	if err := dryRunDB.Model(&Toy{}).Count(&count).Error; err != nil || count != 0 {
+		t.Errorf("Should not count records in dry run mode, but got %v, %v", err, count)
+	}
+
+	var names []string
+	if err := dryRunDB.Model(&Toy{}).Pluck("name", &names).Error; err != nil || len(names) != 0 {
+		t.Errorf("Should not pluck columns in dry run mode, but got %v, %v", err, names)
+	}
+
+	DB.Create(&toy)
+	dryRunDB.Model(&toy).Update("name", "DryRunToyNew")
+	dryRunDB.Exec("UPDATE toys SET name = ?", "DryRunToyNew")
+	dryRunDB.Delete(&toy)
+
+	var result Toy
+	if err := DB.First(&result, toy.Id).Error; err != nil || result.Name != "DryRunToy" {
+		t.Errorf("Should not update or delete record in dry run mode, but got %v, %+v", err, result)
+	}
+}
+
+func TestToSQL(t *testing.T) {
+	sql := DB.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		return tx.Where("name = ? AND owner_id IN (?)", "Toy's name", []int{1, 2}).Find(&[]Toy{})
+	})
+
+	if !strings.HasPrefix(sql, "SELECT * FROM ") || !strings.Contains(sql, "(name = 'Toy''s name' AND owner_id IN (1,2))") {
+		t.Errorf("Should interpolate vars into the query, but got %v", sql)
+	}
+
+	sql = DB.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		return tx.Model(&Toy{Id: 1}).UpdateColumn("name", "ToSQL ? $1")
+	})
+
+	if !strings.HasPrefix(sql, "UPDATE ") || !strings.Contains(sql, "'ToSQL ? $1'") {
+		t.Errorf("Should interpolate vars into the update statement, but got %v", sql)
+	}
+
+	if sql := DB.ToSQL(func(tx *gorm.DB) *gorm.DB { return tx }); sql != "" {
+		t.Errorf("Should get empty statement if nothing is performed, but got %v", sql)
+	}
+}
+
+func logMessage(msg string, data []interface{}) string {
+	if len(data) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, data...)
+}
+
+type recordLogger struct {
+	infos, warns, errors, sqls []string
+}
+
+func (logger *recordLogger) Info(msg string, data ...interface{}) {
+	logger.infos = append(logger.infos, logMessage(msg, data))
+}
+
+func (logger *recordLogger) Warn(msg string, data ...interface{}) {
+	logger.warns = append(logger.warns, logMessage(msg, data))
+}
+
+func (logger *recordLogger) Error(msg string, data ...interface{}) {
+	logger.errors = append(logger.errors, logMessage(msg, data))
+}
+
+func (logger *recordLogger) Trace(begin time.Time, sql string, vars []interface{}, rowsAffected int64, err error) {
+	logger.sqls = append(logger.sqls, fmt.Sprintf("%v %v %v", sql, vars, rowsAffected))
+}
+
+type lineWriter struct {
+	lines []string
+}
+
+func (writer *lineWriter) Println(v ...interface{}) {
+	writer.lines = append(writer.lines, fmt.Sprintln(v...))
+}
+
+func TestLogLevel(t *testing.T) {
+	logger := &recordLogger{}
+	db := DB.New()
+	db.SetLeveledLogger(logger)
+
+	db.Where("unknown_column = ?", 1).Find(&[]Toy{})
+	if len(logger.errors) != 1 || len(logger.sqls) != 0 {
+		t.Errorf("Should only log errors by default, but got %+v", logger)
+	}
+
+	db.SetLogLevel(gorm.LogSilent).Where("unknown_column = ?", 1).Find(&[]Toy{})
+	if len(logger.errors) != 1 {
+		t.Errorf("Should not log errors when silent, but got %+v", logger.errors)
+	}
+
+	db.LogMode(true).Where("name = ?", "LogLevelToy").Find(&[]Toy{})
+	if len(logger.sqls) != 1 || !strings.Contains(logger.sqls[0], "[LogLevelToy] 0") {
+		t.Errorf("Should log sql with vars and rows affected, but got %+v", logger.sqls)
+	}
+
+	db.NewScope(nil).Log("LogLevel", "100%")
+	if len(logger.infos) != 1 || logger.infos[0] != "LogLevel 100%" {
+		t.Errorf("Should log infos, but got %+v", logger.infos)
+	}
+}
+
+func TestTextLogger(t *testing.T) {
+	writer := &lineWriter{}
+	db := DB.New().LogMode(true)
+
+	db.SetLeveledLogger(gorm.NewLogger(writer, false))
+	db.Where("name = ?", "TextLoggerToy").Find(&[]Toy{})
+	if len(writer.lines) != 1 || strings.Contains(writer.lines[0], "\033[") || !strings.Contains(writer.lines[0], "'TextLoggerToy'") {
+		t.Errorf("Should log sql without colours, but got %+v", writer.lines)
+	}
+
+	db.SetLogger(gorm.Logger{LogWriter: writer})
+	db.Where("name = ?", "TextLoggerToy").Find(&[]Toy{})
+	if len(writer.lines) != 2 || !strings.Contains(writer.lines[1], "\033[") || !strings.Contains(writer.lines[1], "0 rows affected or returned") {
+		t.Errorf("Should log sql with the old logger, but got %+v", writer.lines)
+	}
+
+	db.Where("unknown_column = ?", 1).Find(&[]Toy{})
+	if lines := strings.Join(writer.lines[2:], ""); !strings.Contains(lines, "unknown_column = '1'") || !strings.Contains(lines, "[error: ") {
+		t.Errorf("Should log sql with its error, but got %+v", writer.lines[2:])
+	}
+}
+
+func TestJSONLogger(t *testing.T) {
+	var buf bytes.Buffer
+	db := DB.New().LogMode(true)
+	db.SetLeveledLogger(gorm.NewJSONLogger(&buf))
+
+	db.Where("name = ?", "JSONLoggerToy").Find(&[]Toy{})
+	db.Where("unknown_column = ?", 1).Find(&[]Toy{})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Should log a JSON object per line, but got %v", buf.String())
+	}
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatalf("Should log valid JSON, but got %v", err)
+	}
+
+	if entry["level"] != "info" || !strings.Contains(fmt.Sprint(entry["sql"]), "name = ") ||
+		fmt.Sprint(entry["vars"]) != "[JSONLoggerToy]" || entry["rows_affected"] != float64(0) {
+		t.Errorf("Should log sql with vars and rows affected, but got %v", lines[0])
+	}
+
+	for _, line := range lines[1:] {
+		if err := json.Unmarshal([]byte(line), &entry); err != nil || entry["level"] != "error" || entry["error"] == nil && entry["msg"] == nil {
+			t.Errorf("Should log errors, but got %v", line)
+		}
+	}
+}
+
+func TestSlowQuery(t *testing.T) {
+	var events []gorm.SlowQueryEvent
+	db := DB.New().SetSlowThreshold(time.Nanosecond).OnSlowQuery(func(event gorm.SlowQueryEvent) {
+		events = append(events, event)
+	})
+
+	db.Where("name = ?", "SlowQueryToy").Find(&[]Toy{})
+	if len(events) != 1 {
+		t.Fatalf("Should report slow query, but got %v", len(events))
+	}
+
+	if event := events[0]; event.Table != "toys" || !strings.Contains(event.SQL, "name = ") || fmt.Sprint(event.Vars) != "[SlowQueryToy]" ||
+		event.RowsAffected != 0 || event.Duration <= 0 || event.Caller == "" {
+		t.Errorf("Should report slow query with details, but got %+v", event)
+	}
+
+	if db.SetSlowThreshold(time.Hour).Find(&[]Toy{}); len(events) != 1 {
+		t.Errorf("Should not report queries faster than the threshold")
+	}
+
+	logger := &recordLogger{}
+	db = DB.New().SetSlowThreshold(time.Nanosecond)
+	db.SetLeveledLogger(logger)
+
+	if db.Find(&[]Toy{}); len(logger.warns) != 0 {
+		t.Errorf("Should not log slow queries as warnings by default, but got %v", logger.warns)
+	}
+
+	if db.SetLogLevel(gorm.LogWarn).Find(&[]Toy{}); len(logger.warns) != 1 || len(logger.sqls) != 0 {
+		t.Errorf("Should log slow queries as warnings, but got %+v", logger)
+	}
+}
+
 func TestDdlErrors(t *testing.T) {
 	var err error
 
@@ -816,6 +1360,67 @@ func TestBlockGlobalUpdate(t *testing.T) {
 	}
 }
 
+func TestWithContext(t *testing.T) {
+	user := User{Name: "ContextUser", Age: 10}
+	if err := DB.WithContext(context.Background()).Save(&user).Error; err != nil {
+		t.Errorf("No error should happen when saving with context, but got %v", err)
+	}
+
+	var result User
+	if err := DB.WithContext(context.Background()).First(&result, "name = ?", user.Name).Error; err != nil {
+		t.Errorf("No error should happen when querying with context, but got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	db := DB.WithContext(ctx)
+
+	if err := db.First(&User{}, "name = ?", user.Name).Error; err != context.Canceled {
+		t.Errorf("Query with cancelled context should fail, but got %v", err)
+	}
+
+	if err := db.Model(&user).UpdateColumn("age", 20).Error; err != context.Canceled {
+		t.Errorf("Update with cancelled context should fail, but got %v", err)
+	}
+
+	if err := db.Create(&User{Name: "ContextUser2"}).Error; err != context.Canceled {
+		t.Errorf("Create with cancelled context should fail, but got %v", err)
+	}
+
+	if err := db.Delete(&user).Error; err != context.Canceled {
+		t.Errorf("Delete with cancelled context should fail, but got %v", err)
+	}
+
+	if _, err := db.Table("users").Select("name").Rows(); err != context.Canceled {
+		t.Errorf("Rows with cancelled context should fail, but got %v", err)
+	}
+
+	if err := db.Begin().Error; err != context.Canceled {
+		t.Errorf("Begin with cancelled context should fail, but got %v", err)
+	}
+
+	if err := DB.First(&User{}, "name = ? AND age = ?", user.Name, 10).Error; err != nil {
+		t.Errorf("Record should not be changed by cancelled operations, but got %v", err)
+	}
+
+	type contextKey string
+	var scopeCtx context.Context
+	DB.Callback().Query().Before("gorm:query").Register("test:context", func(scope *gorm.Scope) {
+		scopeCtx = scope.Context()
+	})
+	defer DB.Callback().Query().Remove("test:context")
+
+	DB.WithContext(context.WithValue(context.Background(), contextKey("key"), "value")).First(&User{})
+	if scopeCtx == nil || scopeCtx.Value(contextKey("key")) != "value" {
+		t.Errorf("Scope should expose the context of current operation")
+	}
+
+	DB.First(&User{})
+	if scopeCtx != context.Background() {
+		t.Errorf("Scope should return background context by default")
+	}
+}
+
 func BenchmarkGorm(b *testing.B) {
 	b.N = 2000
 	for x := 0; x < b.N; x++ {
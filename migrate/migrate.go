@@ -0,0 +1,246 @@
+// Package migrate runs versioned migrations in order and records applied migrations in a history table
+package migrate
+
+import (
+	"errors"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+var (
+	// ErrMissingID missing id error, happens when registering a migration without id
+	ErrMissingID = errors.New("migration id is missing")
+	// ErrMissingMigrate missing migrate error, happens when registering a migration without `Migrate`
+	ErrMissingMigrate = errors.New("migration function is missing")
+	// ErrDuplicatedID duplicated id error, happens when registering migrations with the same id
+	ErrDuplicatedID = errors.New("duplicated migration id")
+	// ErrUnknownMigration unknown migration error, happens when migrating or rolling back to an id that isn't registered
+	ErrUnknownMigration = errors.New("unknown migration")
+	// ErrNoRollback no rollback error, happens when rolling back a migration without `Rollback`
+	ErrNoRollback = errors.New("migration can't be rolled back")
+	// ErrNoAppliedMigration no applied migration error, happens when calling `RollbackLast` before applying any migration
+	ErrNoAppliedMigration = errors.New("no applied migration")
+	// ErrLocked locked error, happens when another instance is migrating and doesn't finish within `LockTimeout`
+	ErrLocked = errors.New("migrations are locked by another instance")
+)
+
+// DefaultTableName default name of the history table
+const DefaultTableName = "schema_migrations"
+
+// lockID id of the row that locks migrations in the lock table
+const lockID = "lock"
+
+// lockRetryInterval how often to retry taking the lock when waiting for other instances
+var lockRetryInterval = time.Second
+
+// Migration a versioned migration, both functions are run in a transaction,
+// note mysql commits the transaction implicitly when changing schema, so a failed migration might be applied partly
+type Migration struct {
+	// ID unique id of the migration, e.g. `201801021504_create_users`
+	ID string
+	// Migrate apply the migration
+	Migrate func(tx *gorm.DB) error
+	// Rollback revert the migration, could be nil if the migration can't be rolled back
+	Rollback func(tx *gorm.DB) error
+}
+
+// Migrator run registered migrations in order, e.g:
+//     m := migrate.New(db, []*migrate.Migration{
+//       {ID: "201801021504_create_users", Migrate: func(tx *gorm.DB) error { return tx.CreateTable(&User{}).Error }},
+//     })
+//     err := m.Migrate()
+type Migrator struct {
+	// TableName name of the history table, defaults to `schema_migrations`, the lock table is named with suffix `_lock`
+	TableName string
+	// LockTimeout how long to wait for other instances to finish migrating, fail immediately if it is 0,
+	// a lock left by a stopped instance is never released automatically, see `Unlock`
+	LockTimeout time.Duration
+
+	db         *gorm.DB
+	migrations []*Migration
+}
+
+type schemaMigration struct {
+	ID        string `gorm:"primary_key;size:255"`
+	AppliedAt time.Time
+}
+
+type schemaMigrationLock struct {
+	ID       string `gorm:"primary_key;size:255"`
+	LockedAt time.Time
+}
+
+// New create a migrator with migrations in the order they should be applied
+func New(db *gorm.DB, migrations []*Migration) *Migrator {
+	return &Migrator{TableName: DefaultTableName, db: db, migrations: migrations}
+}
+
+// Migrate apply all migrations haven't been applied
+func (m *Migrator) Migrate() error {
+	return m.MigrateTo("")
+}
+
+// MigrateTo apply migrations haven't been applied until the migration of id (inclusive)
+func (m *Migrator) MigrateTo(id string) error {
+	if err := m.validate(id); err != nil {
+		return err
+	}
+
+	return m.withLock(func(applied map[string]bool) error {
+		for _, migration := range m.migrations {
+			if !applied[migration.ID] {
+				if err := m.runMigration(migration); err != nil {
+					return err
+				}
+			}
+
+			if migration.ID == id {
+				break
+			}
+		}
+		return nil
+	})
+}
+
+// RollbackLast rollback the last applied migration
+func (m *Migrator) RollbackLast() error {
+	if err := m.validate(""); err != nil {
+		return err
+	}
+
+	return m.withLock(func(applied map[string]bool) error {
+		for i := len(m.migrations) - 1; i >= 0; i-- {
+			if applied[m.migrations[i].ID] {
+				return m.rollbackMigration(m.migrations[i])
+			}
+		}
+		return ErrNoAppliedMigration
+	})
+}
+
+// RollbackTo rollback applied migrations after the migration of id, the migration of id is kept
+func (m *Migrator) RollbackTo(id string) error {
+	if err := m.validate(id); err != nil {
+		return err
+	}
+
+	return m.withLock(func(applied map[string]bool) error {
+		for i := len(m.migrations) - 1; i >= 0 && m.migrations[i].ID != id; i-- {
+			if applied[m.migrations[i].ID] {
+				if err := m.rollbackMigration(m.migrations[i]); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// Unlock release the lock left by an instance that stopped while migrating, e.g. a crashed process,
+// the lock doesn't expire as migrations could run for long, so it blocks other instances until it is released with `Unlock`
+func (m *Migrator) Unlock() error {
+	return m.db.Table(m.lockTableName()).Delete(schemaMigrationLock{}, "id = ?", lockID).Error
+}
+
+// validate check ids of registered migrations, and the id to migrate or rollback to if it isn't blank
+func (m *Migrator) validate(id string) error {
+	ids := map[string]bool{}
+	for _, migration := range m.migrations {
+		if migration.ID == "" {
+			return ErrMissingID
+		}
+		if migration.Migrate == nil {
+			return ErrMissingMigrate
+		}
+		if ids[migration.ID] {
+			return ErrDuplicatedID
+		}
+		ids[migration.ID] = true
+	}
+
+	if id != "" && !ids[id] {
+		return ErrUnknownMigration
+	}
+	return nil
+}
+
+func (m *Migrator) lockTableName() string {
+	return m.TableName + "_lock"
+}
+
+// withLock run the function with ids of applied migrations after taking the lock, and release the lock after that
+func (m *Migrator) withLock(fc func(applied map[string]bool) error) (err error) {
+	if err = m.lock(); err != nil {
+		return err
+	}
+
+	defer func() {
+		if unlockErr := m.Unlock(); err == nil {
+			err = unlockErr
+		}
+	}()
+
+	if err = m.db.Table(m.TableName).AutoMigrate(&schemaMigration{}).Error; err != nil {
+		return err
+	}
+
+	var ids []string
+	if err = m.db.Table(m.TableName).Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+
+	applied := map[string]bool{}
+	for _, id := range ids {
+		applied[id] = true
+	}
+	return fc(applied)
+}
+
+// lock insert the lock row, instances fail to insert it if it exists, they retry until `LockTimeout`
+func (m *Migrator) lock() error {
+	// instances starting at the same time race to create the lock table, it's fine if others created it
+	lockDB := m.db.Table(m.lockTableName())
+	if err := lockDB.AutoMigrate(&schemaMigrationLock{}).Error; err != nil && !m.db.HasTable(m.lockTableName()) {
+		return err
+	}
+
+	deadline := gorm.NowFunc().Add(m.LockTimeout)
+	for {
+		err := lockDB.Create(&schemaMigrationLock{ID: lockID, LockedAt: gorm.NowFunc()}).Error
+		if err == nil {
+			return nil
+		}
+
+		if lockDB.Where("id = ?", lockID).First(&schemaMigrationLock{}).RecordNotFound() {
+			return err
+		}
+
+		if !gorm.NowFunc().Before(deadline) {
+			return ErrLocked
+		}
+		time.Sleep(lockRetryInterval)
+	}
+}
+
+func (m *Migrator) runMigration(migration *Migration) error {
+	return m.db.Transaction(func(tx *gorm.DB) error {
+		if err := migration.Migrate(tx); err != nil {
+			return err
+		}
+		return tx.Table(m.TableName).Create(&schemaMigration{ID: migration.ID, AppliedAt: gorm.NowFunc()}).Error
+	})
+}
+
+func (m *Migrator) rollbackMigration(migration *Migration) error {
+	if migration.Rollback == nil {
+		return ErrNoRollback
+	}
+
+	return m.db.Transaction(func(tx *gorm.DB) error {
+		if err := migration.Rollback(tx); err != nil {
+			return err
+		}
+		return tx.Table(m.TableName).Delete(schemaMigration{}, "id = ?", migration.ID).Error
+	})
+}
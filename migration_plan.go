@@ -0,0 +1,115 @@
+package gorm
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// MigrationStatement a DDL statement of a migration plan and why it is needed
+type MigrationStatement struct {
+	SQL    string
+	Reason string
+}
+
+// MigrationPlan DDL statements `AutoMigrate` would execute, in the order they would be executed
+type MigrationPlan struct {
+	// Dialect name of the dialect the statements are written for, it is the dialect of the db the plan is made with
+	Dialect    string
+	Statements []MigrationStatement
+}
+
+// migrationPlanner wraps a connection, records executed statements instead of executing them, queries are still run against the connection to read the catalog
+type migrationPlanner struct {
+	SQLCommon
+	dialect    Dialect
+	statements []MigrationStatement
+	reason     string
+	tables     map[string]bool
+}
+
+func (planner *migrationPlanner) Exec(query string, args ...interface{}) (sql.Result, error) {
+	planner.statements = append(planner.statements, MigrationStatement{SQL: interpolateVars(planner.dialect, query, args), Reason: planner.reason})
+	return driver.RowsAffected(0), nil
+}
+
+// MigrationPlan compare the models with the database like `AutoMigrate`, and return DDL statements it would execute without executing them, e.g:
+//     plan, err := db.MigrationPlan(&User{}, &Product{})
+//     err = plan.SaveSQL("migrations/20180102_users.sql")
+// tables created by the plan are not compared with models again, as they don't exist in the catalog yet,
+// the target dialect is the db's dialect, as models are compared with its catalog, plan with a db connected to the database the statements will be applied to
+func (s *DB) MigrationPlan(models ...interface{}) (*MigrationPlan, error) {
+	planner := &migrationPlanner{SQLCommon: s.db, dialect: s.parent.dialect, tables: map[string]bool{}}
+
+	db := s.clone()
+	db.db = planner
+	if err := db.AutoMigrate(models...).Error; err != nil {
+		return nil, err
+	}
+	return &MigrationPlan{Dialect: s.parent.dialect.GetName(), Statements: planner.statements}, nil
+}
+
+// WriteSQL write statements with their reasons as comments, statements are separated with `GO` for mssql, and terminated with semicolons for others
+func (plan *MigrationPlan) WriteSQL(writer io.Writer) error {
+	for _, statement := range plan.Statements {
+		sql := strings.TrimSuffix(strings.TrimSpace(statement.SQL), ";")
+		if plan.Dialect == "mssql" {
+			sql += "\nGO"
+		} else {
+			sql += ";"
+		}
+
+		if _, err := fmt.Fprintf(writer, "-- %v\n%v\n\n", statement.Reason, sql); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SaveSQL save statements to a `.sql` file
+func (plan *MigrationPlan) SaveSQL(filename string) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+
+	if err := plan.WriteSQL(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// migrationPlanner return the planner if migrations are being planned
+func (scope *Scope) migrationPlanner() *migrationPlanner {
+	planner, _ := scope.SQLDB().(*migrationPlanner)
+	return planner
+}
+
+// planReason set the reason of following statements when migrations are being planned
+func (scope *Scope) planReason(format string, args ...interface{}) {
+	if planner := scope.migrationPlanner(); planner != nil {
+		planner.reason = fmt.Sprintf(format, args...)
+	}
+}
+
+// planTable mark the table as created when migrations are being planned
+func (scope *Scope) planTable(tableName string) {
+	if planner := scope.migrationPlanner(); planner != nil {
+		planner.tables[tableName] = true
+	}
+}
+
+// hasTable check the table exists, or is created by planned statements
+func (scope *Scope) hasTable(tableName string) bool {
+	return scope.isPlannedTable(tableName) || scope.Dialect().HasTable(tableName)
+}
+
+// isPlannedTable check the table is created by planned statements, it doesn't exist in the catalog so it can't be compared with models
+func (scope *Scope) isPlannedTable(tableName string) bool {
+	planner := scope.migrationPlanner()
+	return planner != nil && planner.tables[tableName]
+}
@@ -1,15 +1,20 @@
 package gorm_test
 
 import (
+	"bytes"
 	"database/sql"
 	"database/sql/driver"
 	"errors"
 	"fmt"
+	"os"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/jinzhu/gorm"
+	"github.com/jinzhu/gorm/gen"
+	"github.com/jinzhu/gorm/migrate"
 )
 
 type User struct {
@@ -432,3 +437,817 @@ func TestMultipleIndexes(t *testing.T) {
 		t.Error("MultipleIndexes unique index failed")
 	}
 }
+
+type MigrateProduct struct {
+	Id   int64
+	Code string
+}
+
+func TestMigrator(t *testing.T) {
+	DB.DropTableIfExists(&MigrateProduct{}, migrate.DefaultTableName, migrate.DefaultTableName+"_lock")
+
+	migrations := []*migrate.Migration{
+		{
+			ID:       "201801010000_create_migrate_products",
+			Migrate:  func(tx *gorm.DB) error { return tx.CreateTable(&MigrateProduct{}).Error },
+			Rollback: func(tx *gorm.DB) error { return tx.DropTable(&MigrateProduct{}).Error },
+		},
+		{
+			ID: "201801020000_add_migrate_products_code_index",
+			Migrate: func(tx *gorm.DB) error {
+				return tx.Model(&MigrateProduct{}).AddIndex("idx_migrate_products_code", "code").Error
+			},
+			Rollback: func(tx *gorm.DB) error {
+				return tx.Model(&MigrateProduct{}).RemoveIndex("idx_migrate_products_code").Error
+			},
+		},
+		{
+			ID:       "201801030000_seed_migrate_products",
+			Migrate:  func(tx *gorm.DB) error { return tx.Create(&MigrateProduct{Code: "seed"}).Error },
+			Rollback: func(tx *gorm.DB) error { return tx.Delete(MigrateProduct{}, "code = ?", "seed").Error },
+		},
+	}
+
+	appliedIDs := func() (ids []string) {
+		DB.Table(migrate.DefaultTableName).Order("id").Pluck("id", &ids)
+		return
+	}
+
+	m := migrate.New(DB, migrations)
+	if err := m.MigrateTo("201801020000_add_migrate_products_code_index"); err != nil {
+		t.Fatalf("No error should happen when migrating, but got %v", err)
+	}
+
+	if ids := appliedIDs(); len(ids) != 2 || !DB.Dialect().HasIndex("migrate_products", "idx_migrate_products_code") {
+		t.Errorf("Should apply migrations until the given one, but got %v", ids)
+	}
+
+	if err := m.Migrate(); err != nil || len(appliedIDs()) != 3 {
+		t.Errorf("Should apply all migrations, but got %v, %v", err, appliedIDs())
+	}
+
+	var count int
+	if DB.Model(&MigrateProduct{}).Count(&count); count != 1 {
+		t.Errorf("Migrations should be applied only once, but got %v records", count)
+	}
+
+	if err := m.RollbackLast(); err != nil || len(appliedIDs()) != 2 {
+		t.Errorf("Should rollback the last migration, but got %v, %v", err, appliedIDs())
+	}
+
+	if err := m.RollbackTo("201801010000_create_migrate_products"); err != nil || len(appliedIDs()) != 1 {
+		t.Errorf("Should rollback migrations after the given one, but got %v, %v", err, appliedIDs())
+	}
+
+	if DB.Dialect().HasIndex("migrate_products", "idx_migrate_products_code") {
+		t.Errorf("Index should be removed after rolling back")
+	}
+
+	failed := append(migrations, &migrate.Migration{
+		ID: "201801040000_failed",
+		Migrate: func(tx *gorm.DB) error {
+			tx.Create(&MigrateProduct{Code: "failed"})
+			return errors.New("failed")
+		},
+	})
+	if err := migrate.New(DB, failed).Migrate(); err == nil || err.Error() != "failed" {
+		t.Errorf("Should return the error of failed migration, but got %v", err)
+	}
+
+	if ids := appliedIDs(); len(ids) != 3 || !DB.Where("code = ?", "failed").First(&MigrateProduct{}).RecordNotFound() {
+		t.Errorf("Failed migration should be rolled back, but got %v", ids)
+	}
+
+	if err := m.MigrateTo("unknown"); err != migrate.ErrUnknownMigration {
+		t.Errorf("Should get error when migrating to an unknown migration, but got %v", err)
+	}
+
+	if err := migrate.New(DB, append(migrations, migrations[0])).Migrate(); err != migrate.ErrDuplicatedID {
+		t.Errorf("Should get error with duplicated ids, but got %v", err)
+	}
+
+	if err := migrate.New(DB, append(migrations, &migrate.Migration{ID: "201801045000_missing_migrate"})).Migrate(); err != migrate.ErrMissingMigrate {
+		t.Errorf("Should get error with migrations without migrate, but got %v", err)
+	}
+
+	irreversible := migrate.New(DB, append(migrations, &migrate.Migration{
+		ID:      "201801050000_irreversible",
+		Migrate: func(tx *gorm.DB) error { return nil },
+	}))
+	if err := irreversible.Migrate(); err != nil {
+		t.Errorf("No error should happen when migrating, but got %v", err)
+	}
+
+	if err := irreversible.RollbackLast(); err != migrate.ErrNoRollback {
+		t.Errorf("Should get error when rolling back a migration without rollback, but got %v", err)
+	}
+
+	if err := irreversible.RollbackTo("201801050000_irreversible"); err != nil {
+		t.Errorf("No error should happen when rolling back to the last migration, but got %v", err)
+	}
+
+	if err := m.RollbackTo("201801010000_create_migrate_products"); err != nil || len(appliedIDs()) != 2 {
+		t.Errorf("Should rollback migrations it knows, but got %v, %v", err, appliedIDs())
+	}
+
+	DB.Exec("INSERT INTO "+migrate.DefaultTableName+"_lock (id, locked_at) VALUES (?, ?)", "lock", time.Now())
+	if err := m.RollbackLast(); err != migrate.ErrLocked {
+		t.Errorf("Should get error when another instance is migrating, but got %v", err)
+	}
+
+	if err := m.Unlock(); err != nil {
+		t.Errorf("No error should happen when unlocking, but got %v", err)
+	}
+
+	if err := m.RollbackLast(); err != nil || len(appliedIDs()) != 1 || DB.HasTable(&MigrateProduct{}) {
+		t.Errorf("Should be able to rollback after unlocking, but got %v, %v", err, appliedIDs())
+	}
+}
+
+type MigrateCategory struct {
+	Id   int64
+	Name string `gorm:"index"`
+}
+
+func TestMigratorOrder(t *testing.T) {
+	DB.DropTableIfExists("order_migrations", "order_migrations_lock")
+
+	var ran []string
+	migration := func(id string) *migrate.Migration {
+		return &migrate.Migration{ID: id, Migrate: func(tx *gorm.DB) error {
+			ran = append(ran, id)
+			return nil
+		}}
+	}
+
+	// migrations are applied in the order they are registered, not the order of their ids
+	m := migrate.New(DB, []*migrate.Migration{migration("3_first"), migration("1_second"), migration("2_third"), migration("0_fourth")})
+	m.TableName = "order_migrations"
+	if err := m.MigrateTo("1_second"); err != nil || fmt.Sprint(ran) != "[3_first 1_second]" {
+		t.Errorf("Should apply migrations in registered order, but got %v, %v", err, ran)
+	}
+
+	// applied migrations are skipped, including ones applied by other instances
+	DB.Exec("INSERT INTO order_migrations (id, applied_at) VALUES (?, ?)", "2_third", time.Now())
+	ran = nil
+	if err := m.Migrate(); err != nil || fmt.Sprint(ran) != "[0_fourth]" {
+		t.Errorf("Should skip applied migrations, but got %v, %v", err, ran)
+	}
+
+	ran = nil
+	if err := m.Migrate(); err != nil || len(ran) != 0 {
+		t.Errorf("Should not apply migrations again, but got %v, %v", err, ran)
+	}
+}
+
+func TestMigratorRollbackOnError(t *testing.T) {
+	DB.DropTableIfExists(&MigrateCategory{}, "error_migrations", "error_migrations_lock")
+
+	var ran []string
+	m := migrate.New(DB, []*migrate.Migration{
+		{ID: "1_create", Migrate: func(tx *gorm.DB) error {
+			ran = append(ran, "1_create")
+			return tx.CreateTable(&MigrateCategory{}).Error
+		}},
+		{ID: "2_failed", Migrate: func(tx *gorm.DB) error {
+			ran = append(ran, "2_failed")
+			tx.Create(&MigrateCategory{Name: "failed"})
+			tx.Model(&MigrateCategory{}).RenameColumn("name", "title")
+			tx.Model(&MigrateCategory{}).RenameIndex("idx_migrate_categories_name", "idx_migrate_categories_title")
+			return errors.New("failed")
+		}},
+		{ID: "3_skipped", Migrate: func(tx *gorm.DB) error {
+			ran = append(ran, "3_skipped")
+			return nil
+		}},
+	})
+	m.TableName = "error_migrations"
+
+	if err := m.Migrate(); err == nil || err.Error() != "failed" {
+		t.Errorf("Should return the error of failed migration, but got %v", err)
+	}
+
+	if fmt.Sprint(ran) != "[1_create 2_failed]" {
+		t.Errorf("Should stop at the failed migration, but got %v", ran)
+	}
+
+	var ids []string
+	if DB.Table("error_migrations").Pluck("id", &ids); fmt.Sprint(ids) != "[1_create]" {
+		t.Errorf("Should only record succeeded migrations, but got %v", ids)
+	}
+
+	if !DB.Dialect().HasColumn("migrate_categories", "name") || !DB.Dialect().HasIndex("migrate_categories", "idx_migrate_categories_name") {
+		t.Errorf("Schema changes of failed migration should be rolled back")
+	}
+
+	var count int
+	if DB.Model(&MigrateCategory{}).Count(&count); count != 0 {
+		t.Errorf("Data changes of failed migration should be rolled back, but got %v records", count)
+	}
+}
+
+func TestMigratorLock(t *testing.T) {
+	DB.DropTableIfExists("lock_migrations", "lock_migrations_lock")
+
+	started, finish := make(chan bool), make(chan bool)
+	migrations := []*migrate.Migration{{ID: "1_slow", Migrate: func(tx *gorm.DB) error {
+		started <- true
+		<-finish
+		return nil
+	}}}
+
+	m := migrate.New(DB, migrations)
+	m.TableName = "lock_migrations"
+	done := make(chan error)
+	go func() { done <- m.Migrate() }()
+	<-started
+
+	other := migrate.New(DB, migrations)
+	other.TableName = "lock_migrations"
+	if err := other.Migrate(); err != migrate.ErrLocked {
+		t.Errorf("Should get error when another instance is migrating, but got %v", err)
+	}
+
+	finish <- true
+	if err := <-done; err != nil {
+		t.Errorf("No error should happen when migrating, but got %v", err)
+	}
+
+	if err := other.Migrate(); err != nil {
+		t.Errorf("Should take the lock after the other instance finished, but got %v", err)
+	}
+}
+
+type AlterColumnToy struct {
+	Id    int64
+	Name  string `gorm:"size:64"`
+	Color string `gorm:"index"`
+}
+
+type AlterColumnToyChanged struct {
+	Id    int64
+	Name  string `gorm:"size:128;not null;default:'toy'"`
+	Color string `gorm:"index"`
+}
+
+func (AlterColumnToyChanged) TableName() string {
+	return "alter_column_toys"
+}
+
+func TestAutoMigrateAlterColumns(t *testing.T) {
+	DB.DropTableIfExists(&AlterColumnToy{})
+	DB.AutoMigrate(&AlterColumnToy{})
+	DB.Save(&AlterColumnToy{Name: "car", Color: "red"})
+
+	nameColumn := func() (column gorm.ColumnType) {
+		columns, err := DB.Dialect().ColumnTypes("alter_column_toys")
+		if err != nil {
+			t.Errorf("No error should happen when querying column types, but got %v", err)
+		}
+		for _, c := range columns {
+			if c.Name == "name" {
+				column = c
+			}
+		}
+		return
+	}
+
+	if column := nameColumn(); column.DataType != "varchar" || column.Length != 64 || !column.Nullable || column.Default != nil || column.PrimaryKey {
+		t.Errorf("Should get column type of name, but got %+v", column)
+	}
+
+	// mysql's `MODIFY` drops options not written again
+	if os.Getenv("GORM_DIALECT") == "mysql" {
+		DB.Exec("ALTER TABLE alter_column_toys MODIFY name varchar(64) COMMENT 'name of toy'")
+	}
+
+	if err := DB.AutoMigrate(&AlterColumnToyChanged{}).Error; err != nil {
+		t.Errorf("No error should happen when migrating, but got %v", err)
+	}
+
+	// sqlite recreates the table to change columns, which needs destructive migration to be allowed
+	if dialect := os.Getenv("GORM_DIALECT"); dialect == "" || dialect == "sqlite" {
+		if column := nameColumn(); column.Length != 64 || !column.Nullable {
+			t.Errorf("Should not recreate table without allowing destructive migration, but got %+v", column)
+		}
+
+		if err := DB.Set("gorm:allow_destructive_migration", true).AutoMigrate(&AlterColumnToyChanged{}).Error; err != nil {
+			t.Errorf("No error should happen when migrating, but got %v", err)
+		}
+	}
+
+	column := nameColumn()
+	if column.Length != 128 || column.Nullable || !column.SameDefault(gorm.ColumnType{Default: &[]string{"'toy'"}[0]}) {
+		t.Errorf("Should alter changed column, but got %+v", column)
+	}
+
+	if os.Getenv("GORM_DIALECT") == "mysql" {
+		if _, comments, _ := DB.Dialect().Comments("alter_column_toys"); comments["name"] != "name of toy" {
+			t.Errorf("Should keep comment of altered column, but got %v", comments)
+		}
+	}
+
+	var toy AlterColumnToyChanged
+	if DB.First(&toy, "color = ?", "red"); toy.Name != "car" {
+		t.Errorf("Should keep data after altering columns, but got %+v", toy)
+	}
+
+	if !DB.Dialect().HasIndex("alter_column_toys", "idx_alter_column_toys_color") {
+		t.Errorf("Should keep indexes after altering columns")
+	}
+
+	var defaultToy AlterColumnToyChanged
+	DB.Exec("INSERT INTO alter_column_toys (color) VALUES (?)", "blue")
+	if DB.First(&defaultToy, "color = ?", "blue"); defaultToy.Name != "toy" {
+		t.Errorf("Should use new default value, but got %+v", defaultToy)
+	}
+}
+
+type ConstraintCompany struct {
+	Id        int64
+	Name      string
+	Employees []ConstraintEmployee `gorm:"constraint:OnDelete:CASCADE"`
+}
+
+type ConstraintEmployee struct {
+	Id                  int64
+	Name                string
+	ConstraintCompanyId int64
+	Skills              []ConstraintSkill `gorm:"many2many:constraint_employee_skills;constraint:OnDelete:CASCADE,OnUpdate:CASCADE"`
+}
+
+type ConstraintSkill struct {
+	Id   int64
+	Name string
+}
+
+func TestForeignKeyConstraints(t *testing.T) {
+	DB.DropTableIfExists("constraint_employee_skills", &ConstraintEmployee{}, &ConstraintCompany{}, &ConstraintSkill{})
+
+	// employees are migrated before the companies they reference
+	if err := DB.AutoMigrate(&ConstraintEmployee{}, &ConstraintCompany{}, &ConstraintSkill{}).Error; err != nil {
+		t.Errorf("No error should happen when migrating, but got %v", err)
+	}
+
+	dialect := DB.Dialect()
+	foreignKeys := map[string]string{
+		"constraint_employees":       dialect.BuildForeignKeyName("constraint_employees", "constraint_company_id", "constraint_companies(id)"),
+		"constraint_employee_skills": dialect.BuildForeignKeyName("constraint_employee_skills", "constraint_skill_id", "constraint_skills(id)"),
+	}
+	for table, name := range foreignKeys {
+		if !dialect.HasForeignKey(table, name) {
+			t.Errorf("Should create foreign key %v on %v", name, table)
+		}
+	}
+
+	if err := DB.AutoMigrate(&ConstraintEmployee{}, &ConstraintCompany{}, &ConstraintSkill{}).Error; err != nil {
+		t.Errorf("No error should happen when migrating again, but got %v", err)
+	}
+
+	// sqlite doesn't enforce foreign keys unless `PRAGMA foreign_keys` is on for the connection
+	if dialect := os.Getenv("GORM_DIALECT"); dialect != "" && dialect != "sqlite" {
+		company := ConstraintCompany{Name: "company", Employees: []ConstraintEmployee{{Name: "employee"}}}
+		DB.Save(&company)
+		if err := DB.Delete(&company).Error; err != nil {
+			t.Errorf("No error should happen when deleting company, but got %v", err)
+		}
+
+		if !DB.First(&ConstraintEmployee{}, "name = ?", "employee").RecordNotFound() {
+			t.Errorf("Employees should be deleted by cascade")
+		}
+
+		if err := DB.Save(&ConstraintEmployee{Name: "orphan", ConstraintCompanyId: company.Id}).Error; err == nil {
+			t.Errorf("Should get error when referencing a company doesn't exist")
+		}
+	}
+
+	DB.DropTableIfExists("constraint_employee_skills", &ConstraintEmployee{}, &ConstraintCompany{}, &ConstraintSkill{})
+	DB.DisableForeignKeyConstraints(true)
+	defer DB.DisableForeignKeyConstraints(false)
+
+	DB.AutoMigrate(&ConstraintEmployee{}, &ConstraintCompany{}, &ConstraintSkill{})
+	for table, name := range foreignKeys {
+		if dialect.HasForeignKey(table, name) {
+			t.Errorf("Should not create foreign key %v on %v after disabling constraints", name, table)
+		}
+	}
+}
+
+type CheckToy struct {
+	Id     int64
+	Name   string
+	Age    int `gorm:"check:age >= 0"`
+	MaxAge int
+}
+
+func (CheckToy) CheckConstraints() []gorm.Check {
+	return []gorm.Check{{Name: "chk_check_toys_max_age", Constraint: "age <= max_age"}}
+}
+
+type CheckToyWithoutChecks struct {
+	Id     int64
+	Name   string
+	Age    int
+	MaxAge int
+}
+
+func (CheckToyWithoutChecks) TableName() string {
+	return "check_toys"
+}
+
+func TestCheckConstraints(t *testing.T) {
+	DB.DropTableIfExists(&CheckToy{})
+	if err := DB.CreateTable(&CheckToy{}).Error; err != nil {
+		t.Errorf("No error should happen when creating table, but got %v", err)
+	}
+
+	for _, name := range []string{"chk_check_toys_age", "chk_check_toys_max_age"} {
+		if !DB.Dialect().HasConstraint("check_toys", name) {
+			t.Errorf("Should create check constraint %v", name)
+		}
+	}
+
+	if err := DB.Save(&CheckToy{Name: "negative", Age: -1, MaxAge: 10}).Error; err == nil {
+		t.Errorf("Should get error when violating check constraint of tag")
+	}
+
+	if err := DB.Save(&CheckToy{Name: "too old", Age: 11, MaxAge: 10}).Error; err == nil {
+		t.Errorf("Should get error when violating check constraint of method")
+	}
+
+	if err := DB.Save(&CheckToy{Name: "valid", Age: 5, MaxAge: 10}).Error; err != nil {
+		t.Errorf("No error should happen when saving valid data, but got %v", err)
+	}
+
+	DB.DropTableIfExists(&CheckToy{})
+	DB.AutoMigrate(&CheckToyWithoutChecks{})
+	DB.Save(&CheckToyWithoutChecks{Name: "existing", Age: 1, MaxAge: 10})
+
+	migrateDB := DB
+	// sqlite recreates the table to add constraints, which needs destructive migration to be allowed
+	if dialect := os.Getenv("GORM_DIALECT"); dialect == "" || dialect == "sqlite" {
+		if DB.AutoMigrate(&CheckToy{}); DB.Dialect().HasConstraint("check_toys", "chk_check_toys_age") {
+			t.Errorf("Should not recreate table without allowing destructive migration")
+		}
+		migrateDB = DB.Set("gorm:allow_destructive_migration", true)
+	}
+
+	if err := migrateDB.AutoMigrate(&CheckToy{}).Error; err != nil {
+		t.Errorf("No error should happen when migrating, but got %v", err)
+	}
+
+	if !DB.Dialect().HasConstraint("check_toys", "chk_check_toys_age") || !DB.Dialect().HasConstraint("check_toys", "chk_check_toys_max_age") {
+		t.Errorf("Should add check constraints to existing table")
+	}
+
+	if DB.First(&CheckToy{}, "name = ?", "existing").RecordNotFound() {
+		t.Errorf("Should keep data after adding check constraints")
+	}
+
+	if err := DB.Save(&CheckToy{Name: "negative", Age: -1, MaxAge: 10}).Error; err == nil {
+		t.Errorf("Should get error when violating added check constraint")
+	}
+
+	err := DB.Model(&CheckToy{}).DropConstraint("chk_check_toys_age").Error
+	if dialect := os.Getenv("GORM_DIALECT"); dialect == "" || dialect == "sqlite" {
+		if err != gorm.ErrUnsupportedConstraint {
+			t.Errorf("Should get error when dropping constraint with sqlite, but got %v", err)
+		}
+	} else if err != nil || DB.Dialect().HasConstraint("check_toys", "chk_check_toys_age") {
+		t.Errorf("Should drop check constraint, but got %v", err)
+	}
+
+	// constraint statements are executed by the scope, so they are built in dry run mode instead of being executed
+	pgDB, _ := gorm.Open("postgres", DB.DB())
+	statement := pgDB.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		return tx.Model(&CheckToy{}).DropConstraint("chk_check_toys_age")
+	})
+	if expected := `ALTER TABLE "check_toys" DROP CONSTRAINT "chk_check_toys_age"`; statement != expected {
+		t.Errorf("Should build sql to drop constraint, but got %v", statement)
+	}
+}
+
+type RichIndexToy struct {
+	Id        int64
+	Name      string `gorm:"index:idx_rich_index_toys_email_name,priority:2"`
+	Email     string `gorm:"index:idx_rich_index_toys_email_name,priority:1,sort:desc;unique_index:uix_rich_index_toys_lower_email,expression:lower(email),where:deleted_at IS NULL AND name NOT IN ('a,b', 'c')"`
+	DeletedAt *time.Time
+}
+
+func TestRichIndexes(t *testing.T) {
+	if dialect := os.Getenv("GORM_DIALECT"); dialect == "mysql" || dialect == "mssql" {
+		t.Skip("Skipping this because mysql doesn't support partial indexes, and mssql doesn't support expression indexes")
+	}
+
+	DB.DropTableIfExists(&RichIndexToy{})
+	if err := DB.AutoMigrate(&RichIndexToy{}).Error; err != nil {
+		t.Errorf("No error should happen when migrating, but got %v", err)
+	}
+
+	for _, name := range []string{"idx_rich_index_toys_email_name", "uix_rich_index_toys_lower_email"} {
+		if !DB.Dialect().HasIndex("rich_index_toys", name) {
+			t.Errorf("Should create index %v", name)
+		}
+	}
+
+	if dialect := os.Getenv("GORM_DIALECT"); dialect == "" || dialect == "sqlite" {
+		var sql string
+		DB.Raw("SELECT sql FROM sqlite_master WHERE type = 'index' AND name = ?", "idx_rich_index_toys_email_name").Row().Scan(&sql)
+		if !strings.Contains(sql, `("email" DESC, "name")`) {
+			t.Errorf("Should order index columns by priority with sort direction, but got %v", sql)
+		}
+	}
+
+	if err := DB.Save(&RichIndexToy{Name: "toy", Email: "Toy@example.org"}).Error; err != nil {
+		t.Errorf("No error should happen when saving, but got %v", err)
+	}
+
+	if err := DB.Save(&RichIndexToy{Name: "toy", Email: "toy@example.org"}).Error; err == nil {
+		t.Errorf("Should get error when violating unique expression index")
+	}
+
+	now := time.Now()
+	if err := DB.Save(&RichIndexToy{Name: "toy", Email: "toy@example.org", DeletedAt: &now}).Error; err != nil {
+		t.Errorf("Deleted records should be excluded from partial index, but got %v", err)
+	}
+
+	if err := DB.Model(&RichIndexToy{}).AddIndex("idx_rich_index_toys_name_desc", "name", "sort:desc").Error; err != nil {
+		t.Errorf("No error should happen when adding index with options, but got %v", err)
+	}
+
+	if !DB.Dialect().HasIndex("rich_index_toys", "idx_rich_index_toys_name_desc") {
+		t.Errorf("Should add index with options")
+	}
+
+	if dialect := os.Getenv("GORM_DIALECT"); dialect == "" || dialect == "sqlite" {
+		if err := DB.Model(&RichIndexToy{}).AddIndex("idx_rich_index_toys_name_gin", "name", "type:gin").Error; err != gorm.ErrUnsupportedIndex {
+			t.Errorf("Should get error when adding index with unsupported type, but got %v", err)
+		}
+	}
+}
+
+type PlanToy struct {
+	Id    int64
+	Name  string `gorm:"index"`
+	Color string
+}
+
+type PlanToyWithoutColor struct {
+	Id   int64
+	Name string
+}
+
+func (PlanToyWithoutColor) TableName() string {
+	return "plan_toys"
+}
+
+func TestMigrationPlan(t *testing.T) {
+	DB.DropTableIfExists(&PlanToy{})
+
+	plan, err := DB.MigrationPlan(&PlanToy{})
+	if err != nil {
+		t.Errorf("No error should happen when planning migrations, but got %v", err)
+	}
+
+	if DB.HasTable(&PlanToy{}) {
+		t.Errorf("Should not create table when planning migrations")
+	}
+
+	if len(plan.Statements) != 2 || !strings.HasPrefix(plan.Statements[0].SQL, "CREATE TABLE") || !strings.Contains(plan.Statements[1].SQL, "idx_plan_toys_name") {
+		t.Fatalf("Should plan creating table and index, but got %+v", plan.Statements)
+	}
+
+	if plan.Statements[0].Reason != "create table `plan_toys`" || plan.Statements[1].Reason != "create index `idx_plan_toys_name` on `plan_toys`" {
+		t.Errorf("Should explain planned statements, but got %+v", plan.Statements)
+	}
+
+	var buf bytes.Buffer
+	if err := plan.WriteSQL(&buf); err != nil {
+		t.Errorf("No error should happen when writing sql, but got %v", err)
+	}
+
+	if sql := buf.String(); !strings.HasPrefix(sql, "-- create table `plan_toys`\nCREATE TABLE") || strings.Count(sql, ";\n") != 2 {
+		t.Errorf("Should write planned statements with reasons, but got %v", sql)
+	}
+
+	DB.AutoMigrate(&PlanToyWithoutColor{})
+	if plan, err = DB.MigrationPlan(&PlanToy{}); err != nil {
+		t.Errorf("No error should happen when planning migrations, but got %v", err)
+	}
+
+	if len(plan.Statements) != 2 || !strings.Contains(plan.Statements[0].SQL, "ADD") || plan.Statements[0].Reason != "add column `color` to `plan_toys`" {
+		t.Errorf("Should plan adding column to existing table, but got %+v", plan.Statements)
+	}
+
+	if DB.Dialect().HasColumn("plan_toys", "color") {
+		t.Errorf("Should not add column when planning migrations")
+	}
+}
+
+type AliasTypeToy struct {
+	Id        int64
+	Name      string  `gorm:"size:64;not null;default:'toy'"`
+	Price     float64 `gorm:"type:decimal(10,2)"`
+	Quantity  int     `gorm:"type:integer"`
+	Available bool
+	Code      string `gorm:"unique_index"`
+	Nickname  string `gorm:"type:varchar(100) NOT NULL"`
+	Color     string `gorm:"type:varchar(32) NOT NULL DEFAULT 'light blue'"`
+	CreatedAt time.Time
+}
+
+func TestAutoMigrateWithoutChanges(t *testing.T) {
+	DB.DropTableIfExists(&AliasTypeToy{})
+	if err := DB.AutoMigrate(&AliasTypeToy{}).Error; err != nil {
+		t.Fatalf("No error should happen when migrating, but got %v", err)
+	}
+
+	// aliases reported by the server like `numeric` for `decimal` are the same types, constraints written in tag `type` are compared too
+	if plan, err := DB.Set("gorm:allow_destructive_migration", true).MigrationPlan(&AliasTypeToy{}); err != nil || len(plan.Statements) != 0 {
+		t.Errorf("Should plan nothing when migrating again, but got %v, %+v", err, plan)
+	}
+
+	pgDB, _ := gorm.Open("postgres", DB.DB())
+	if column := pgDB.Dialect().NormalizeColumnType(gorm.ColumnType{DataType: "decimal"}); column.DataType != "numeric" {
+		t.Errorf("Should normalize postgres aliases, but got %v", column.DataType)
+	}
+	if column := pgDB.Dialect().NormalizeColumnType(gorm.ColumnType{DataType: "int4"}); column.DataType != "integer" {
+		t.Errorf("Should normalize postgres internal names, but got %v", column.DataType)
+	}
+
+	mysqlDB, _ := gorm.Open("mysql", DB.DB())
+	if column := mysqlDB.Dialect().NormalizeColumnType(gorm.ColumnType{Type: "tinyint(1)", DataType: "tinyint", Length: 1}); column.DataType != "boolean" {
+		t.Errorf("Should normalize mysql tinyint(1) as boolean, but got %v", column.DataType)
+	}
+	if column := mysqlDB.Dialect().NormalizeColumnType(gorm.ColumnType{DataType: "bool"}); column.DataType != "boolean" {
+		t.Errorf("Should normalize mysql aliases, but got %v", column.DataType)
+	}
+}
+
+type RenameToy struct {
+	Id   int64
+	Name string `gorm:"index;not null;default:'toy'"`
+}
+
+type RenamedToy struct {
+	Id       int64
+	FullName string
+}
+
+func TestRenameColumnTableAndIndex(t *testing.T) {
+	DB.DropTableIfExists(&RenameToy{}, &RenamedToy{})
+	DB.AutoMigrate(&RenameToy{})
+	DB.Save(&RenameToy{Name: "car"})
+
+	DB.Transaction(func(tx *gorm.DB) error {
+		tx.Model(&RenameToy{}).RenameColumn("name", "full_name")
+		tx.RenameTable("rename_toys", "renamed_toys")
+		return errors.New("rollback")
+	})
+
+	if !DB.HasTable("rename_toys") || !DB.Dialect().HasColumn("rename_toys", "name") {
+		t.Errorf("Should rollback renaming inside transaction")
+	}
+
+	if err := DB.Model(&RenameToy{}).RenameColumn("name", "full_name").Error; err != nil {
+		t.Errorf("No error should happen when renaming column, but got %v", err)
+	}
+
+	var columns []string
+	columnTypes, _ := DB.Dialect().ColumnTypes("rename_toys")
+	for _, column := range columnTypes {
+		columns = append(columns, column.Name)
+	}
+
+	if fmt.Sprint(columns) != "[id full_name]" {
+		t.Errorf("Should rename column, but got %v", columns)
+	}
+
+	if err := DB.Model(&RenameToy{}).RenameIndex("idx_rename_toys_name", "idx_rename_toys_full_name").Error; err != nil {
+		t.Errorf("No error should happen when renaming index, but got %v", err)
+	}
+
+	if DB.Dialect().HasIndex("rename_toys", "idx_rename_toys_name") || !DB.Dialect().HasIndex("rename_toys", "idx_rename_toys_full_name") {
+		t.Errorf("Should rename index")
+	}
+
+	if err := DB.RenameTable("rename_toys", "renamed_toys").Error; err != nil {
+		t.Errorf("No error should happen when renaming table, but got %v", err)
+	}
+
+	if DB.HasTable("rename_toys") || !DB.HasTable("renamed_toys") {
+		t.Errorf("Should rename table")
+	}
+
+	var toy RenamedToy
+	if DB.First(&toy); toy.FullName != "car" {
+		t.Errorf("Should keep data after renaming, but got %+v", toy)
+	}
+
+	if err := DB.Exec("INSERT INTO renamed_toys (id) VALUES (?)", 2).Error; err != nil {
+		t.Errorf("Should keep column definition after renaming, but got %v", err)
+	}
+}
+
+type CommentToy struct {
+	Id   int64
+	Name string `gorm:"comment:name of the toy"`
+}
+
+func (CommentToy) TableComment() string {
+	return "toys with comments"
+}
+
+type CommentToyChanged struct {
+	Id   int64
+	Name string `gorm:"comment:display name of the toy"`
+}
+
+func (CommentToyChanged) TableName() string {
+	return "comment_toys"
+}
+
+func (CommentToyChanged) TableComment() string {
+	return "toys with changed comments"
+}
+
+func TestComments(t *testing.T) {
+	DB.DropTableIfExists(&CommentToy{})
+	if err := DB.AutoMigrate(&CommentToy{}).Error; err != nil {
+		t.Errorf("No error should happen when creating table with comments, but got %v", err)
+	}
+
+	tableComment, columnComments, err := DB.Dialect().Comments("comment_toys")
+	if err != nil {
+		t.Errorf("No error should happen when querying comments, but got %v", err)
+	}
+
+	// sqlite doesn't support comments
+	if dialect := os.Getenv("GORM_DIALECT"); dialect == "" || dialect == "sqlite" {
+		if tableComment != "" || len(columnComments) != 0 {
+			t.Errorf("Should skip comments for sqlite, but got %v, %v", tableComment, columnComments)
+		}
+		return
+	}
+
+	if tableComment != "toys with comments" || columnComments["name"] != "name of the toy" {
+		t.Errorf("Should create comments, but got %v, %v", tableComment, columnComments)
+	}
+
+	if err := DB.AutoMigrate(&CommentToyChanged{}).Error; err != nil {
+		t.Errorf("No error should happen when updating comments, but got %v", err)
+	}
+
+	tableComment, columnComments, _ = DB.Dialect().Comments("comment_toys")
+	if tableComment != "toys with changed comments" || columnComments["name"] != "display name of the toy" {
+		t.Errorf("Should update changed comments, but got %v, %v", tableComment, columnComments)
+	}
+}
+
+type GenCompany struct {
+	Id        int64
+	Name      string `gorm:"size:64;not null"`
+	Code      string `gorm:"size:16;unique_index"`
+	Employees []GenEmployee
+}
+
+type GenEmployee struct {
+	Id           int64
+	GenCompanyID int64  `gorm:"index:idx_gen_employees_company_name,priority:1"`
+	Name         string `gorm:"size:64;index:idx_gen_employees_company_name,priority:2"`
+	HiredAt      *time.Time
+}
+
+func TestGenerateModels(t *testing.T) {
+	DB.DropTableIfExists(&GenEmployee{}, &GenCompany{})
+	DB.AutoMigrate(&GenCompany{}, &GenEmployee{})
+
+	source, err := gen.Generate(DB, gen.Config{PackageName: "legacy", Tables: []string{"gen_companies", "gen_employees"}})
+	if err != nil {
+		t.Fatalf("No error should happen when generating models, but got %v", err)
+	}
+
+	// fields are aligned by gofmt
+	code := strings.Join(strings.Fields(string(source)), " ")
+	for _, expected := range []string{
+		"package legacy",
+		"type GenCompany struct",
+		"func (GenCompany) TableName() string",
+		`gorm:"column:name;type:varchar(64);size:64;not null"`,
+		`gorm:"column:code;type:varchar(16);size:16;unique_index:uix_gen_companies_code"`,
+		`gorm:"column:gen_company_id;type:bigint;index:idx_gen_employees_company_name,priority:1"`,
+		"HiredAt *time.Time",
+		`GenEmployees []GenEmployee` + " `gorm:\"foreignkey:GenCompanyID\"`",
+		`GenCompany *GenCompany` + " `gorm:\"foreignkey:GenCompanyID\"`",
+	} {
+		if !strings.Contains(code, expected) {
+			t.Errorf("Generated models should contain %v, but got %v", expected, code)
+		}
+	}
+
+	if dialect := os.Getenv("GORM_DIALECT"); dialect == "" || dialect == "sqlite" {
+		if !strings.Contains(code, `gorm:"column:id;type:integer;primary_key"`) {
+			t.Errorf("Generated models should contain primary key, but got %v", code)
+		}
+	}
+}
@@ -12,3 +12,11 @@ type Model struct {
 	UpdatedAt time.Time
 	DeletedAt *time.Time `sql:"index"`
 }
+
+// Version optimistic lock version, a field of this type or tagged with `gorm:"version"` is checked and increased when updating,
+// updating a stale record returns `ErrStaleObject`
+//    type Product struct {
+//      gorm.Model
+//      Version gorm.Version
+//    }
+type Version int64
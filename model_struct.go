@@ -56,9 +56,11 @@ func (s *ModelStruct) TableName(db *DB) string {
 			s.defaultTableName = tabler.TableName()
 		} else {
 			tableName := ToDBName(s.ModelType.Name())
-			if db == nil || !db.parent.singularTable {
+			db.parent.RLock()
+			if !db.parent.singularTable {
 				tableName = inflection.Plural(tableName)
 			}
+			db.parent.RUnlock()
 			s.defaultTableName = tableName
 		}
 	}
@@ -187,6 +189,11 @@ func (scope *Scope) GetModelStruct() *ModelStruct {
 					indirectType = indirectType.Elem()
 				}
 
+				// is optimistic lock version
+				if indirectType == reflect.TypeOf(Version(0)) {
+					field.TagSettings["VERSION"] = "VERSION"
+				}
+
 				fieldValue := reflect.New(indirectType).Interface()
 				if _, isScanner := fieldValue.(sql.Scanner); isScanner {
 					// is scanner
@@ -0,0 +1,235 @@
+package gorm
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// OnConflict specify how to resolve conflicts on unique keys when creating records, it is passed to `Clauses`, or set with `gorm:on_conflict`, e.g:
+//     // INSERT INTO "users" ... ON CONFLICT ("email") DO UPDATE SET "name" = excluded."name"
+//     db.Clauses(gorm.OnConflict{Columns: []string{"email"}, UpdateColumns: []string{"name"}}).Create(&user)
+// Primary keys of conflicted records will be set to the existing records' primary keys after creating, `gorm:insert_option` is ignored when upserting
+type OnConflict struct {
+	// Columns conflict target, defaults to primary keys when updating, to columns of the first unique index or primary keys when doing nothing,
+	// mysql always resolves conflicts on all unique keys
+	Columns []string
+	// Where condition to update conflicted records created with `Expr`, e.g. gorm.Expr("users.age < ?", 18)
+	Where *SQLExpr
+	// DoNothing skip conflicted records
+	DoNothing bool
+	// UpdateColumns update these columns of conflicted records with inserting values
+	UpdateColumns []string
+	// UpdateAll update all inserting columns except primary keys, conflict columns and `created_at`
+	UpdateAll bool
+}
+
+func (scope *Scope) onConflict() (OnConflict, bool) {
+	if value, ok := scope.Get("gorm:on_conflict"); ok {
+		switch onConflict := value.(type) {
+		case OnConflict:
+			return onConflict, true
+		case *OnConflict:
+			if onConflict != nil {
+				return *onConflict, true
+			}
+		}
+	}
+	return OnConflict{}, false
+}
+
+// resolve convert field names to db names, expand `UpdateAll` to update columns and fill the conflict target, dialects render the resolved option
+func (onConflict OnConflict) resolve(scope *Scope, columns []string) (OnConflict, error) {
+	toDBNames := func(names []string) (dbNames []string) {
+		for _, name := range names {
+			if field, ok := scope.FieldByName(name); ok {
+				dbNames = append(dbNames, field.DBName)
+			} else {
+				dbNames = append(dbNames, name)
+			}
+		}
+		return
+	}
+
+	resolved := OnConflict{Columns: toDBNames(onConflict.Columns), Where: onConflict.Where, DoNothing: onConflict.DoNothing}
+
+	if !resolved.DoNothing {
+		if onConflict.UpdateAll {
+			for _, column := range columns {
+				if field, ok := scope.FieldByName(column); ok && field.IsPrimaryKey {
+					continue
+				}
+				if column != "created_at" && !strInSlice(column, resolved.Columns) {
+					resolved.UpdateColumns = append(resolved.UpdateColumns, column)
+				}
+			}
+		} else {
+			resolved.UpdateColumns = toDBNames(onConflict.UpdateColumns)
+		}
+
+		// nothing to update
+		resolved.DoNothing = len(resolved.UpdateColumns) == 0
+	}
+
+	if len(resolved.Columns) == 0 {
+		// skipped records are matched by the conflict target to set their primary keys, which are usually conflicted on unique keys
+		if resolved.DoNothing {
+			resolved.Columns = scope.uniqueColumns()
+		}
+
+		if len(resolved.Columns) == 0 {
+			for _, field := range scope.PrimaryFields() {
+				resolved.Columns = append(resolved.Columns, field.DBName)
+			}
+		}
+
+		if len(resolved.Columns) == 0 {
+			return resolved, ErrMissingConflictColumns
+		}
+	}
+	return resolved, nil
+}
+
+// uniqueColumns return db names of columns of the first unique index or unique column of the model, partial and expression indexes are skipped
+func (scope *Scope) uniqueColumns() []string {
+	var (
+		names   []string
+		indexes = map[string]*Index{}
+		dbNames = map[string]string{}
+	)
+
+	for _, field := range scope.GetStructFields() {
+		if field.IsIgnored || !field.IsNormal {
+			continue
+		}
+		dbNames[scope.Quote(field.DBName)] = field.DBName
+
+		var uniqueIndexes []*Index
+		if _, ok := field.TagSettings["UNIQUE"]; ok {
+			uniqueIndexes = append(uniqueIndexes, &Index{Name: "unique " + field.DBName, Unique: true, Columns: []IndexColumn{{Name: scope.Quote(field.DBName), priority: defaultIndexPriority}}})
+		}
+		if value, ok := field.TagSettings["UNIQUE_INDEX"]; ok {
+			uniqueIndexes = append(uniqueIndexes, scope.parseIndexTag(field, value, true, fmt.Sprintf("uix_%v_%v", scope.TableName(), field.DBName))...)
+		}
+
+		for _, index := range uniqueIndexes {
+			if existing, ok := indexes[index.Name]; ok {
+				existing.merge(index)
+			} else {
+				names = append(names, index.Name)
+				indexes[index.Name] = index
+			}
+		}
+	}
+
+	for _, name := range names {
+		index := indexes[name]
+		if index.Where != "" {
+			continue
+		}
+
+		var columns []string
+		index.sortColumns()
+		for _, column := range index.Columns {
+			if dbName, ok := dbNames[column.Name]; ok && column.Expression == "" {
+				columns = append(columns, dbName)
+			}
+		}
+
+		if len(columns) == len(index.Columns) {
+			return columns
+		}
+	}
+	return nil
+}
+
+// queryPrimaryKeysByConflictColumns set primary keys of upserted records by querying them with conflict columns,
+// as LastInsertId and RETURNING can't tell which records are updated or skipped, each record is queried with its own select
+// tagged by its index, so records are matched by the database like conflicts are, e.g. with case insensitive collations
+func queryPrimaryKeysByConflictColumns(scope *Scope, batch []*Scope, conflictColumns []string) {
+	primaryField := batch[0].PrimaryField()
+	if primaryField == nil || len(conflictColumns) == 0 || strInSlice(primaryField.DBName, conflictColumns) {
+		return
+	}
+
+	var (
+		selects []string
+		vars    []interface{}
+	)
+
+	for idx, elemScope := range batch {
+		var (
+			conditions []string
+			values     []interface{}
+		)
+
+		for _, column := range conflictColumns {
+			field, ok := elemScope.FieldByName(column)
+			if !ok {
+				return
+			}
+			conditions = append(conditions, fmt.Sprintf("%v = ?", scope.Quote(column)))
+			values = append(values, field.Field.Interface())
+		}
+
+		// null values never conflict
+		if !hasNullValue(values) {
+			selects = append(selects, fmt.Sprintf("SELECT %d, %v FROM %v WHERE %v", idx, scope.Quote(primaryField.DBName), scope.QuotedTableName(), strings.Join(conditions, " AND ")))
+			vars = append(vars, values...)
+		}
+
+		// dbs limit selects of a compound query, e.g. sqlite allows 500
+		if len(selects) == maxConflictSelects || (idx == len(batch)-1 && len(selects) > 0) {
+			if !setPrimaryKeysByConflictSelects(scope, batch, selects, vars) {
+				return
+			}
+			selects, vars = nil, nil
+		}
+	}
+}
+
+// maxConflictSelects how many records are queried with a query by `queryPrimaryKeysByConflictColumns`
+const maxConflictSelects = 100
+
+// setPrimaryKeysByConflictSelects run selects returning indexes of records and their primary keys, return false if any error happened
+func setPrimaryKeysByConflictSelects(scope *Scope, batch []*Scope, selects []string, vars []interface{}) bool {
+	primaryField := batch[0].PrimaryField()
+	rows, err := scope.NewDB().Raw(strings.Join(selects, " UNION ALL "), vars...).Rows()
+	if scope.Err(err) != nil {
+		return false
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			idx          int
+			primaryValue = reflect.New(primaryField.Struct.Type)
+		)
+
+		if scope.Err(rows.Scan(&idx, primaryValue.Interface())) != nil {
+			return false
+		}
+
+		if idx >= 0 && idx < len(batch) {
+			scope.Err(batch[idx].PrimaryField().Set(primaryValue.Elem()))
+		}
+	}
+	return scope.Err(rows.Err()) == nil
+}
+
+// hasNullValue check any of the values is nil, a nil pointer, or a `driver.Valuer` whose value is nil
+func hasNullValue(values []interface{}) bool {
+	for _, value := range values {
+		reflectValue := indirect(reflect.ValueOf(value))
+		if !reflectValue.IsValid() {
+			return true
+		}
+		if valuer, ok := reflectValue.Interface().(driver.Valuer); ok {
+			if v, err := valuer.Value(); err != nil || v == nil {
+				return true
+			}
+		}
+	}
+	return false
+}
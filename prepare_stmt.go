@@ -0,0 +1,228 @@
+package gorm
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"sync"
+)
+
+// defaultPreparedStmtCacheSize default max number of cached prepared statements
+const defaultPreparedStmtCacheSize = 200
+
+// preparedStmtDB wraps a connection, caches prepared statements by sql, least recently used statements are closed when the cache is full
+type preparedStmtDB struct {
+	db      SQLCommon
+	maxSize int
+	mutex   sync.Mutex
+	stmts   map[string]*list.Element
+	lru     *list.List
+}
+
+// preparedStmt a cached statement, evicted statements are closed after the last user releases them,
+// so statements being executed by other goroutines aren't closed
+type preparedStmt struct {
+	query   string
+	stmt    *sql.Stmt
+	users   int
+	evicted bool
+}
+
+func newPreparedStmtDB(db SQLCommon, maxSize int) *preparedStmtDB {
+	if maxSize <= 0 {
+		maxSize = defaultPreparedStmtCacheSize
+	}
+	return &preparedStmtDB{db: db, maxSize: maxSize, stmts: map[string]*list.Element{}, lru: list.New()}
+}
+
+// prepare return cached statement of the sql, or prepare and cache a new one, the statement must be released with `release` after using it
+func (db *preparedStmtDB) prepare(ctx context.Context, query string) (*preparedStmt, error) {
+	db.mutex.Lock()
+	if elem, ok := db.stmts[query]; ok {
+		db.lru.MoveToFront(elem)
+		cached := elem.Value.(*preparedStmt)
+		cached.users++
+		db.mutex.Unlock()
+		return cached, nil
+	}
+	db.mutex.Unlock()
+
+	var (
+		stmt *sql.Stmt
+		err  error
+	)
+	if preparer, ok := db.db.(interface {
+		PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+	}); ok {
+		stmt, err = preparer.PrepareContext(ctx, query)
+	} else {
+		stmt, err = db.db.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+
+	// prepared by others at the same time
+	if elem, ok := db.stmts[query]; ok {
+		stmt.Close()
+		db.lru.MoveToFront(elem)
+		cached := elem.Value.(*preparedStmt)
+		cached.users++
+		return cached, nil
+	}
+
+	prepared := &preparedStmt{query: query, stmt: stmt, users: 1}
+	db.stmts[query] = db.lru.PushFront(prepared)
+	for db.lru.Len() > db.maxSize {
+		evicted := db.lru.Remove(db.lru.Back()).(*preparedStmt)
+		delete(db.stmts, evicted.query)
+		evicted.evict()
+	}
+	return prepared, nil
+}
+
+// release release the statement returned by `prepare`, it is closed if it has been evicted and isn't used by others
+func (db *preparedStmtDB) release(prepared *preparedStmt) {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+
+	prepared.users--
+	if prepared.evicted && prepared.users == 0 {
+		prepared.stmt.Close()
+	}
+}
+
+// evict mark the statement evicted, it is closed now if it isn't used, otherwise by its last user, the mutex must be held
+func (prepared *preparedStmt) evict() {
+	prepared.evicted = true
+	if prepared.users == 0 {
+		prepared.stmt.Close()
+	}
+}
+
+// closeStmts close all cached statements, statements being used are closed after they are released
+func (db *preparedStmtDB) closeStmts() {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+
+	for _, elem := range db.stmts {
+		elem.Value.(*preparedStmt).evict()
+	}
+	db.stmts = map[string]*list.Element{}
+	db.lru.Init()
+}
+
+// Close close cached statements and the wrapped connection
+func (db *preparedStmtDB) Close() error {
+	db.closeStmts()
+	if closer, ok := db.db.(closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+func (db *preparedStmtDB) Begin() (*sql.Tx, error) {
+	if beginner, ok := db.db.(sqlDb); ok {
+		return beginner.Begin()
+	}
+	return nil, ErrCantStartTransaction
+}
+
+func (db *preparedStmtDB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	if beginner, ok := db.db.(sqlDbContext); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+	return db.Begin()
+}
+
+func (db *preparedStmtDB) Prepare(query string) (*sql.Stmt, error) {
+	return db.db.Prepare(query)
+}
+
+func (db *preparedStmtDB) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return db.ExecContext(context.Background(), query, args...)
+}
+
+func (db *preparedStmtDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	prepared, err := db.prepare(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer db.release(prepared)
+	return prepared.stmt.ExecContext(ctx, args...)
+}
+
+func (db *preparedStmtDB) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return db.QueryContext(context.Background(), query, args...)
+}
+
+func (db *preparedStmtDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	prepared, err := db.prepare(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer db.release(prepared)
+	return prepared.stmt.QueryContext(ctx, args...)
+}
+
+func (db *preparedStmtDB) QueryRow(query string, args ...interface{}) *sql.Row {
+	return db.QueryRowContext(context.Background(), query, args...)
+}
+
+func (db *preparedStmtDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	prepared, err := db.prepare(ctx, query)
+	if err != nil {
+		// sql.Row can't be built with an error, let the connection report it
+		return db.db.QueryRow(query, args...)
+	}
+	defer db.release(prepared)
+	return prepared.stmt.QueryRowContext(ctx, args...)
+}
+
+// preparedStmtTx a transaction started from preparedStmtDB, cached statements are re-bound to it with `tx.Stmt`
+type preparedStmtTx struct {
+	*sql.Tx
+	stmtDB *preparedStmtDB
+}
+
+func (tx *preparedStmtTx) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return tx.ExecContext(context.Background(), query, args...)
+}
+
+func (tx *preparedStmtTx) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	prepared, err := tx.stmtDB.prepare(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.stmtDB.release(prepared)
+	return tx.StmtContext(ctx, prepared.stmt).ExecContext(ctx, args...)
+}
+
+func (tx *preparedStmtTx) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return tx.QueryContext(context.Background(), query, args...)
+}
+
+func (tx *preparedStmtTx) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	prepared, err := tx.stmtDB.prepare(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.stmtDB.release(prepared)
+	return tx.StmtContext(ctx, prepared.stmt).QueryContext(ctx, args...)
+}
+
+func (tx *preparedStmtTx) QueryRow(query string, args ...interface{}) *sql.Row {
+	return tx.QueryRowContext(context.Background(), query, args...)
+}
+
+func (tx *preparedStmtTx) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	prepared, err := tx.stmtDB.prepare(ctx, query)
+	if err != nil {
+		return tx.Tx.QueryRowContext(ctx, query, args...)
+	}
+	defer tx.stmtDB.release(prepared)
+	return tx.StmtContext(ctx, prepared.stmt).QueryRowContext(ctx, args...)
+}
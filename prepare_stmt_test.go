@@ -0,0 +1,66 @@
+package gorm
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	_ "github.com/jinzhu/gorm/dialects/sqlite"
+)
+
+func TestPreparedStmtEvictedWhileUsed(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open connection, got %v", err)
+	}
+	defer db.Close()
+
+	// every statement evicts the previous one
+	stmtDB := newPreparedStmtDB(db, 1)
+	defer stmtDB.Close()
+
+	prepared := make(chan *preparedStmt)
+	evicted := make(chan bool)
+	result := make(chan error)
+	go func() {
+		used, err := stmtDB.prepare(context.Background(), "SELECT 1")
+		if err != nil {
+			result <- err
+			return
+		}
+		prepared <- used
+		<-evicted
+
+		var value int
+		err = used.stmt.QueryRow().Scan(&value)
+		stmtDB.release(used)
+		result <- err
+	}()
+
+	var used *preparedStmt
+	select {
+	case used = <-prepared:
+	case err := <-result:
+		t.Fatalf("Failed to prepare statement, got %v", err)
+	}
+
+	go func() {
+		var value int
+		if err := stmtDB.QueryRow("SELECT 2").Scan(&value); err != nil {
+			t.Errorf("No error should happen when evicting statements, but got %v", err)
+		}
+		evicted <- true
+	}()
+
+	if err := <-result; err != nil {
+		t.Errorf("Evicted statement should be usable until it is released, but got %v", err)
+	}
+
+	if !used.evicted || used.users != 0 {
+		t.Errorf("Statement should be evicted and released, but got %+v", used)
+	}
+
+	if _, err := used.stmt.Query(); err == nil {
+		t.Errorf("Evicted statement should be closed after it is released")
+	}
+}
@@ -1,10 +1,13 @@
 package gorm_test
 
 import (
+	"database/sql"
 	"fmt"
 	"reflect"
+	"strings"
 
 	"github.com/jinzhu/gorm"
+	"github.com/jinzhu/gorm/clause"
 
 	"testing"
 	"time"
@@ -665,3 +668,264 @@ func TestSelectWithArrayInput(t *testing.T) {
 		t.Errorf("Should have selected both age and name")
 	}
 }
+
+func TestSubQuery(t *testing.T) {
+	for _, age := range []int64{10, 20, 30} {
+		DB.Save(&User{Name: "subquery_user", Age: age})
+	}
+
+	var users []User
+	DB.Where("name = ? AND age > (?)", "subquery_user", DB.Table("users").Select("AVG(age)").Where("name = ?", "subquery_user")).Find(&users)
+	if len(users) != 1 || users[0].Age != 30 {
+		t.Errorf("Should find users with subquery in where conditions, but got %+v", users)
+	}
+
+	var results []struct {
+		Name   string
+		MaxAge int64
+	}
+	DB.Table("users").Select("name, (?) AS max_age", DB.Table("users").Select("MAX(age)").Where("name = ?", "subquery_user")).Where("name = ?", "subquery_user").Scan(&results)
+	if len(results) != 3 || results[0].MaxAge != 30 {
+		t.Errorf("Should select subquery, but got %+v", results)
+	}
+
+	users = nil
+	DB.Joins("JOIN (?) AS t ON t.name = users.name AND t.max_age = users.age", DB.Table("users").Select("name, MAX(age) AS max_age").Where("name = ?", "subquery_user").Group("name")).Find(&users)
+	if len(users) != 1 || users[0].Age != 30 {
+		t.Errorf("Should join subquery, but got %+v", users)
+	}
+
+	var count int
+	DB.Table("(?) AS u", DB.Table("users").Select("name, age").Where("name = ?", "subquery_user")).Where("u.age > ?", 15).Count(&count)
+	if count != 2 {
+		t.Errorf("Should query from subquery, but got %v", count)
+	}
+
+	// postgres numbers placeholders, vars are interpolated by their numbers
+	pgDB, _ := gorm.Open("postgres", DB.DB())
+	statement := pgDB.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		return tx.Where("name = ? AND age > (?) AND age < ?", "a", tx.Table("users").Select("AVG(age)").Where("name = ?", "b"), 100).Find(&users)
+	})
+	if expected := `SELECT * FROM "users" WHERE (name = 'a' AND age > (SELECT AVG(age) FROM "users" WHERE (name = 'b')) AND age < 100)`; statement != expected {
+		t.Errorf("Should number placeholders of subquery after outer ones, but got %v", statement)
+	}
+}
+
+func TestNamedArgs(t *testing.T) {
+	DB.Save(&User{Name: "named_arg_user", Age: 18})
+	DB.Save(&User{Name: "named_arg_user_2", Age: 20})
+
+	var users []User
+	DB.Where("name = @name OR (name LIKE @name || '%' AND age > @age)", sql.Named("name", "named_arg_user"), sql.Named("age", 18)).Order("age").Find(&users)
+	if len(users) != 2 || users[1].Name != "named_arg_user_2" {
+		t.Errorf("Should find users with sql.Named args, but got %+v", users)
+	}
+
+	users = nil
+	DB.Where("name IN (@names) AND age < @age", map[string]interface{}{"names": []string{"named_arg_user", "named_arg_user_2"}, "age": 20}).Find(&users)
+	if len(users) != 1 || users[0].Name != "named_arg_user" {
+		t.Errorf("Should find users with map args, but got %+v", users)
+	}
+
+	var user User
+	DB.Raw("SELECT * FROM users WHERE name = @Name AND age = @age AND name <> '@name'", User{Name: "named_arg_user_2", Age: 20}).Scan(&user)
+	if user.Name != "named_arg_user_2" {
+		t.Errorf("Should query raw sql with struct args, but got %+v", user)
+	}
+
+	if err := DB.Exec("UPDATE users SET age = @age WHERE name = @name", sql.Named("name", "named_arg_user"), sql.Named("age", 19)).Error; err != nil {
+		t.Errorf("No error should happen when executing sql with named args, but got %v", err)
+	}
+
+	var names []string
+	DB.Model(&User{}).Where("name LIKE @prefix", map[string]interface{}{"prefix": "named_arg_user%"}).Group("name").Having("MAX(age) > @age", sql.Named("age", 18)).Pluck("name", &names)
+	if len(names) != 2 {
+		t.Errorf("Should group users having named args, but got %v", names)
+	}
+
+	// postgres numbers placeholders, vars are interpolated by their numbers
+	pgDB, _ := gorm.Open("postgres", DB.DB())
+	statement := pgDB.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		return tx.Where("age > ? AND (name = @name OR nickname = @name)", 1, sql.Named("name", "jinzhu")).Find(&users)
+	})
+	if expected := `SELECT * FROM "users" WHERE (age > 1 AND (name = 'jinzhu' OR nickname = 'jinzhu'))`; statement != expected {
+		t.Errorf("Should bind positional and named args in order, but got %v", statement)
+	}
+}
+
+type GroupToy struct {
+	Id        int64
+	Name      string
+	Age       int
+	DeletedAt *time.Time
+}
+
+func TestGroupConditions(t *testing.T) {
+	DB.DropTableIfExists(&GroupToy{})
+	DB.AutoMigrate(&GroupToy{})
+
+	toys := []GroupToy{{Name: "car", Age: 1}, {Name: "car", Age: 2}, {Name: "car", Age: 3}, {Name: "doll", Age: 1}}
+	for i := range toys {
+		DB.Save(&toys[i])
+	}
+
+	var results []GroupToy
+	DB.Where("name = ?", "car").Where(DB.Where("age = ?", 1).Or("age = ?", 3)).Order("age").Find(&results)
+	if len(results) != 2 || results[0].Age != 1 || results[1].Age != 3 {
+		t.Errorf("Should group conditions of the query, but got %+v", results)
+	}
+
+	results = nil
+	DB.Where(gorm.Or(gorm.Expr("age = ?", 2), gorm.And("age = 1", map[string]interface{}{"name": "doll"}))).Order("age").Find(&results)
+	if len(results) != 2 || results[0].Name != "doll" || results[1].Age != 2 {
+		t.Errorf("Should combine nested condition groups, but got %+v", results)
+	}
+
+	results = nil
+	DB.Not(gorm.Or("age = 1", "age = 2")).Find(&results)
+	if len(results) != 1 || results[0].Age != 3 {
+		t.Errorf("Should negate condition groups, but got %+v", results)
+	}
+
+	DB.Delete(&toys[0])
+	results = nil
+	DB.Where(gorm.Or("age = 1", "age = 3")).Find(&results)
+	if len(results) != 2 {
+		t.Errorf("Should exclude soft deleted records from condition groups, but got %+v", results)
+	}
+
+	DB.Model(&toys[2]).Where(gorm.Or("age = 1", "age = 3")).Update("name", "truck")
+	var count int
+	if DB.Model(&GroupToy{}).Where("name = ?", "truck").Count(&count); count != 1 {
+		t.Errorf("Should combine condition groups with primary key conditions, but got %v", count)
+	}
+
+	statement := DB.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		return tx.Where("name = ?", "car").Where(gorm.Or(gorm.Expr("age = ?", 1), tx.Where("age > ?", 2).Where("age < ?", 5))).Find(&results)
+	})
+	if !strings.Contains(statement, `WHERE ("group_toys"."deleted_at" IS NULL) AND ((name = 'car') AND ((age = 1) OR ((age > 2) AND (age < 5))))`) {
+		t.Errorf("Should build condition groups with parentheses, but got %v", statement)
+	}
+}
+
+func TestClauseConditions(t *testing.T) {
+	DB.DropTableIfExists(&GroupToy{})
+	DB.AutoMigrate(&GroupToy{})
+
+	toys := []GroupToy{{Name: "car", Age: 1}, {Name: "car", Age: 2}, {Name: "doll", Age: 3}}
+	for i := range toys {
+		DB.Save(&toys[i])
+	}
+
+	var results []GroupToy
+	DB.Where(clause.Eq{Column: "name", Value: "car"}).Not(clause.Lt{Column: "age", Value: 2}).Find(&results)
+	if len(results) != 1 || results[0].Age != 2 {
+		t.Errorf("Should query with clause expressions as conditions, but got %+v", results)
+	}
+
+	if _, ok := gorm.Or("age = 1", map[string]interface{}{"name": "doll"}).(clause.OrConditions); !ok {
+		t.Errorf("Condition groups should be clause expressions")
+	}
+
+	// callbacks could inspect and rewrite conditions before the where clause is built
+	var conditions []clause.Expression
+	DB.Callback().Query().Before("gorm:query").Register("test:conditions", func(scope *gorm.Scope) {
+		conditions = scope.Search.WhereConditions
+		scope.Search.WhereConditions = append(conditions, clause.Neq{Column: "name", Value: "doll"})
+	})
+	defer DB.Callback().Query().Remove("test:conditions")
+
+	results = nil
+	DB.Where("age > ?", 1).Where(map[string]interface{}{"deleted_at": nil}).Where([]int64{toys[1].Id, toys[2].Id}).Find(&results)
+	if len(results) != 1 || results[0].Name != "car" {
+		t.Errorf("Should query with conditions rewritten by callbacks, but got %+v", results)
+	}
+
+	if len(conditions) != 3 {
+		t.Fatalf("Callbacks should get conditions of the query, but got %#v", conditions)
+	}
+
+	if expr, ok := conditions[0].(clause.Expr); !ok || expr.SQL != "age > ?" || !reflect.DeepEqual(expr.Vars, []interface{}{1}) {
+		t.Errorf("Conditions of raw sql should be clause.Expr, but got %#v", conditions[0])
+	}
+
+	if eq, ok := conditions[1].(clause.Eq); !ok || eq.Column != (clause.Column{Table: clause.CurrentTable, Name: "deleted_at"}) || eq.Value != nil {
+		t.Errorf("Conditions of maps should be clause.Eq of columns of the table, but got %#v", conditions[1])
+	}
+
+	if in, ok := conditions[2].(clause.IN); !ok || in.Column != (clause.Column{Table: clause.CurrentTable, Name: clause.PrimaryKey}) || len(in.Values) != 2 {
+		t.Errorf("Conditions of primary keys should be clause.IN of the primary key, but got %#v", conditions[2])
+	}
+}
+
+func TestClauses(t *testing.T) {
+	DB.Save(&User{Name: "clause_user", Age: 10})
+	DB.Save(&User{Name: "clause_user", Age: 20})
+	DB.Save(&User{Name: "clause_user", Age: 30})
+
+	var users []User
+	DB.Where("name = ?", "clause_user").Clauses(
+		clause.Where{Exprs: []clause.Expression{clause.Or(clause.Gt{Column: "age", Value: 25}, clause.IN{Column: "age", Values: []interface{}{10, 15}})}},
+		clause.OrderBy{Columns: []clause.OrderByColumn{{Column: clause.Column{Name: "age"}, Desc: true}}},
+	).Find(&users)
+	if len(users) != 2 || users[0].Age != 30 || users[1].Age != 10 {
+		t.Errorf("Should merge where clauses into conditions, but got %+v", users)
+	}
+
+	var names []string
+	DB.Model(&User{}).Clauses(clause.Select{Distinct: true, Columns: []clause.Column{{Name: "name"}}}).Where("name = ?", "clause_user").Pluck("name", &names)
+	if len(names) != 1 {
+		t.Errorf("Should select distinct columns, but got %v", names)
+	}
+
+	var user User
+	DB.Where("name = ? AND age = ?", "clause_user", 20).First(&user)
+	DB.Model(&user).Clauses(clause.Set{{Column: clause.Column{Name: "age"}, Value: clause.Expr{SQL: "age + ?", Vars: []interface{}{5}}}}).Update("name", "clause_user_2")
+	if DB.First(&user, user.Id); user.Name != "clause_user_2" || user.Age != 25 {
+		t.Errorf("Should merge set clauses into updated columns, but got %+v", user)
+	}
+
+	if err := DB.Clauses(clause.Limit{Limit: 1}).Where("name = ?", "clause_user").Find(&users).Error; err != nil || len(users) != 1 {
+		t.Errorf("Should limit the query with the limit clause, but got %v, %v", users, err)
+	}
+
+	// postgres numbers placeholders, vars are interpolated by their numbers
+	pgDB, _ := gorm.Open("postgres", DB.DB())
+	statement := pgDB.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		return tx.Where("name = ?", "a").Clauses(clause.Where{Exprs: []clause.Expression{clause.Eq{Column: "age", Value: 18}}}, clause.Limit{Limit: 10, Offset: 5}).Find(&users)
+	})
+	if expected := `SELECT * FROM "users" WHERE ((name = 'a')) AND ("age" = 18) LIMIT 10 OFFSET 5`; statement != expected {
+		t.Errorf("Should render clauses through the dialect, but got %v", statement)
+	}
+
+	if err := DB.Clauses(clause.Where{}).Find(&users).Error; err != nil {
+		t.Errorf("No error should happen when where clause has no conditions, but got %v", err)
+	}
+}
+
+func TestLocking(t *testing.T) {
+	DB.Save(&User{Name: "locking_user", Age: 10})
+
+	// sqlite locks the whole database, locking is skipped
+	var user User
+	if err := DB.Locking(gorm.ForUpdate, gorm.SkipLocked).Where("name = ?", "locking_user").First(&user).Error; err != nil || user.Name != "locking_user" {
+		t.Errorf("Should skip locking for sqlite, but got %+v, %v", user, err)
+	}
+
+	if err := DB.Locking(gorm.NoWait).First(&user).Error; err != gorm.ErrUnsupportedLocking {
+		t.Errorf("Should return error when locking without a strength, but got %v", err)
+	}
+
+	if err := DB.Locking(gorm.ForUpdate, gorm.ForShare).First(&user).Error; err != gorm.ErrUnsupportedLocking {
+		t.Errorf("Should return error when locking with multiple strengths, but got %v", err)
+	}
+
+	var users []User
+	pgDB, _ := gorm.Open("postgres", DB.DB())
+	statement := pgDB.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		return tx.Locking(gorm.ForUpdate, gorm.SkipLocked).Where("name = ?", "a").Limit(1).Find(&users)
+	})
+	if expected := `SELECT * FROM "users" WHERE (name = 'a') LIMIT 1 FOR UPDATE SKIP LOCKED`; statement != expected {
+		t.Errorf("Should render locking through the dialect, but got %v", statement)
+	}
+}
@@ -0,0 +1,76 @@
+package gorm
+
+import (
+	"math/rand"
+	"sync/atomic"
+)
+
+// ReplicaPolicy choose a replica connection to read data from
+type ReplicaPolicy interface {
+	Resolve(replicas []SQLCommon) SQLCommon
+}
+
+// RandomPolicy choose a random replica for each query
+type RandomPolicy struct{}
+
+// Resolve return a random replica
+func (RandomPolicy) Resolve(replicas []SQLCommon) SQLCommon {
+	return replicas[rand.Intn(len(replicas))]
+}
+
+// RoundRobinPolicy choose replicas in turn, it should be used as a pointer, e.g: `&gorm.RoundRobinPolicy{}`
+type RoundRobinPolicy struct {
+	next uint64
+}
+
+// Resolve return the next replica
+func (policy *RoundRobinPolicy) Resolve(replicas []SQLCommon) SQLCommon {
+	return replicas[(atomic.AddUint64(&policy.next, 1)-1)%uint64(len(replicas))]
+}
+
+type usePrimary struct{}
+
+// UsePrimary force reading from the primary connection when replicas are registered, e.g:
+//     db.Clauses(gorm.UsePrimary).First(&user)
+var UsePrimary = usePrimary{}
+
+type replicaResolver struct {
+	replicas []SQLCommon
+	policy   ReplicaPolicy
+}
+
+// UseReplicas register replica connections, queries, row queries and preloads will read from a replica chosen by the policy,
+// writes, raw `Exec` and everything inside a transaction still use the primary connection, the policy defaults to `RandomPolicy`,
+// replicas are still owned by the caller, `Close` doesn't close them
+//     db.UseReplicas(&gorm.RoundRobinPolicy{}, replica1, replica2)
+func (s *DB) UseReplicas(policy ReplicaPolicy, replicas ...SQLCommon) *DB {
+	if policy == nil {
+		policy = RandomPolicy{}
+	}
+
+	if len(replicas) == 0 {
+		s.parent.resolver = nil
+	} else {
+		s.parent.resolver = &replicaResolver{replicas: replicas, policy: policy}
+	}
+	return s
+}
+
+// readDB return the connection used to read data, it is a replica unless replicas are not registered,
+// the scope is in a transaction, or the primary connection is forced with `UsePrimary`
+func (scope *Scope) readDB() SQLCommon {
+	if scope.db == nil || scope.db.parent == nil || scope.db.parent.resolver == nil {
+		return scope.SQLDB()
+	}
+
+	if _, inTransaction := scope.SQLDB().(sqlTx); inTransaction {
+		return scope.SQLDB()
+	}
+
+	if value, ok := scope.Get("gorm:use_primary"); ok && value == true {
+		return scope.SQLDB()
+	}
+
+	resolver := scope.db.parent.resolver
+	return resolver.policy.Resolve(resolver.replicas)
+}
@@ -1,16 +1,18 @@
 package gorm
 
 import (
+	"bytes"
+	"context"
 	"database/sql"
-	"database/sql/driver"
 	"errors"
 	"fmt"
 	"regexp"
-	"strconv"
 	"strings"
 	"time"
 
 	"reflect"
+
+	"github.com/jinzhu/gorm/clause"
 )
 
 // Scope contain current operation's information when you perform any operation on the database
@@ -25,6 +27,7 @@ type Scope struct {
 	skipLeft        bool
 	fields          *[]*Field
 	selectAttrs     *[]string
+	sqlBuilder      bytes.Buffer
 }
 
 // IndirectValue return scope's reflect value's indirect value
@@ -62,6 +65,14 @@ func (scope *Scope) SQLDB() SQLCommon {
 	return scope.db.db
 }
 
+// Context return the context of current operation, set with `DB.WithContext`, defaults to `context.Background()`
+func (scope *Scope) Context() context.Context {
+	if scope.db != nil && scope.db.ctx != nil {
+		return scope.db.ctx
+	}
+	return context.Background()
+}
+
 // Dialect get dialect
 func (scope *Scope) Dialect() Dialect {
 	return scope.db.parent.dialect
@@ -253,7 +264,7 @@ func (scope *Scope) CallMethod(methodName string) {
 
 // AddToVars add value as sql's vars, used to prevent SQL injection
 func (scope *Scope) AddToVars(value interface{}) string {
-	if expr, ok := value.(*expr); ok {
+	if expr, ok := value.(*SQLExpr); ok {
 		exp := expr.expr
 		for _, arg := range expr.args {
 			exp = strings.Replace(exp, "?", scope.AddToVars(arg), 1)
@@ -261,6 +272,10 @@ func (scope *Scope) AddToVars(value interface{}) string {
 		return exp
 	}
 
+	if db, ok := value.(*DB); ok {
+		return scope.subQuerySQL(db)
+	}
+
 	scope.SQLVars = append(scope.SQLVars, value)
 	return scope.Dialect().BindVar(len(scope.SQLVars))
 }
@@ -348,8 +363,8 @@ func (scope *Scope) Raw(sql string) *Scope {
 func (scope *Scope) Exec() *Scope {
 	defer scope.trace(NowFunc())
 
-	if !scope.HasError() {
-		if result, err := scope.SQLDB().Exec(scope.SQL, scope.SQLVars...); scope.Err(err) == nil {
+	if !scope.HasError() && !scope.isDryRun() {
+		if result, err := scope.sqlExec(scope.SQL, scope.SQLVars...); scope.Err(err) == nil {
 			if count, err := result.RowsAffected(); scope.Err(err) == nil {
 				scope.db.RowsAffected = count
 			}
@@ -389,11 +404,13 @@ func (scope *Scope) InstanceGet(name string) (interface{}, bool) {
 
 // Begin start a transaction
 func (scope *Scope) Begin() *Scope {
-	if db, ok := scope.SQLDB().(sqlDb); ok {
-		if tx, err := db.Begin(); err == nil {
-			scope.db.db = interface{}(tx).(SQLCommon)
-			scope.InstanceSet("gorm:started_transaction", true)
-		}
+	if scope.isDryRun() {
+		return scope
+	}
+
+	if tx, err := beginTransaction(scope.SQLDB(), scope.Context()); err == nil {
+		scope.db.db = tx
+		scope.InstanceSet("gorm:started_transaction", true)
 	}
 	return scope
 }
@@ -417,6 +434,68 @@ func (scope *Scope) CommitOrRollback() *Scope {
 // Private Methods For *gorm.Scope
 ////////////////////////////////////////////////////////////////////////////////
 
+// elemScopes return a scope for each element if current value is a slice, they share current db and search,
+// so settings, errors and the transaction are shared with current scope, scopes are reused by later callbacks
+func (scope *Scope) elemScopes() (scopes []*Scope, isSlice bool) {
+	indirectScopeValue := scope.IndirectValue()
+	if indirectScopeValue.Kind() != reflect.Slice {
+		return nil, false
+	}
+
+	if elemScopes, ok := scope.InstanceGet("gorm:elem_scopes"); ok {
+		return elemScopes.([]*Scope), true
+	}
+	defer func() { scope.InstanceSet("gorm:elem_scopes", scopes) }()
+
+	for i := 0; i < indirectScopeValue.Len(); i++ {
+		elem := indirectScopeValue.Index(i)
+		if elem.Kind() == reflect.Ptr {
+			if elem.IsNil() {
+				continue
+			}
+		} else if elem.CanAddr() {
+			elem = elem.Addr()
+		}
+		scopes = append(scopes, &Scope{db: scope.db, Search: scope.Search, Value: elem.Interface()})
+	}
+	return scopes, true
+}
+
+// isDryRun return true if statements should be built without being executed, set with `DB.DryRun`
+func (scope *Scope) isDryRun() bool {
+	dryRun, ok := scope.Get("gorm:dry_run")
+	return ok && dryRun == true
+}
+
+func (scope *Scope) sqlExec(query string, args ...interface{}) (sql.Result, error) {
+	if db, ok := scope.SQLDB().(sqlCommonContext); ok {
+		return db.ExecContext(scope.Context(), query, args...)
+	}
+	return scope.SQLDB().Exec(query, args...)
+}
+
+func (scope *Scope) sqlQuery(query string, args ...interface{}) (*sql.Rows, error) {
+	return scope.sqlQueryWith(scope.SQLDB(), query, args...)
+}
+
+func (scope *Scope) sqlQueryRow(query string, args ...interface{}) *sql.Row {
+	return scope.sqlQueryRowWith(scope.SQLDB(), query, args...)
+}
+
+func (scope *Scope) sqlQueryWith(db SQLCommon, query string, args ...interface{}) (*sql.Rows, error) {
+	if db, ok := db.(sqlCommonContext); ok {
+		return db.QueryContext(scope.Context(), query, args...)
+	}
+	return db.Query(query, args...)
+}
+
+func (scope *Scope) sqlQueryRowWith(db SQLCommon, query string, args ...interface{}) *sql.Row {
+	if db, ok := db.(sqlCommonContext); ok {
+		return db.QueryRowContext(scope.Context(), query, args...)
+	}
+	return db.QueryRow(query, args...)
+}
+
 func (scope *Scope) callMethod(methodName string, reflectValue reflect.Value) {
 	// Only get address from non-pointer
 	if reflectValue.CanAddr() && reflectValue.Kind() != reflect.Ptr {
@@ -468,10 +547,15 @@ func (scope *Scope) scan(rows *sql.Rows, columns []string, fields []*Field) {
 		selectFields       []*Field
 		selectedColumnsMap = map[string]int{}
 		resetFields        = map[int]*Field{}
+		joinedStructs      = map[string]*joinedStruct{}
 	)
 
 	for index, column := range columns {
 		values[index] = &ignored
+		if target, ok := scope.joinedColumnTarget(index, column, fields, joinedStructs); ok {
+			values[index] = target
+			continue
+		}
 
 		selectFields = fields
 		if idx, ok := selectedColumnsMap[column]; ok {
@@ -505,257 +589,21 @@ func (scope *Scope) scan(rows *sql.Rows, columns []string, fields []*Field) {
 			field.Field.Set(v)
 		}
 	}
-}
-
-func (scope *Scope) primaryCondition(value interface{}) string {
-	return fmt.Sprintf("(%v.%v = %v)", scope.QuotedTableName(), scope.Quote(scope.PrimaryKey()), value)
-}
-
-func (scope *Scope) buildWhereCondition(clause map[string]interface{}) (str string) {
-	switch value := clause["query"].(type) {
-	case string:
-		if isNumberRegexp.MatchString(value) {
-			return scope.primaryCondition(scope.AddToVars(value))
-		} else if value != "" {
-			str = fmt.Sprintf("(%v)", value)
-		}
-	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, sql.NullInt64:
-		return scope.primaryCondition(scope.AddToVars(value))
-	case []int, []int8, []int16, []int32, []int64, []uint, []uint8, []uint16, []uint32, []uint64, []string, []interface{}:
-		str = fmt.Sprintf("(%v.%v IN (?))", scope.QuotedTableName(), scope.Quote(scope.PrimaryKey()))
-		clause["args"] = []interface{}{value}
-	case map[string]interface{}:
-		var sqls []string
-		for key, value := range value {
-			if value != nil {
-				sqls = append(sqls, fmt.Sprintf("(%v.%v = %v)", scope.QuotedTableName(), scope.Quote(key), scope.AddToVars(value)))
-			} else {
-				sqls = append(sqls, fmt.Sprintf("(%v.%v IS NULL)", scope.QuotedTableName(), scope.Quote(key)))
-			}
-		}
-		return strings.Join(sqls, " AND ")
-	case interface{}:
-		var sqls []string
-		newScope := scope.New(value)
-		for _, field := range newScope.Fields() {
-			if !field.IsIgnored && !field.IsBlank {
-				sqls = append(sqls, fmt.Sprintf("(%v.%v = %v)", scope.QuotedTableName(), scope.Quote(field.DBName), scope.AddToVars(field.Field.Interface())))
-			}
-		}
-		return strings.Join(sqls, " AND ")
-	}
-
-	args := clause["args"].([]interface{})
-	for _, arg := range args {
-		switch reflect.ValueOf(arg).Kind() {
-		case reflect.Slice: // For where("id in (?)", []int64{1,2})
-			if bytes, ok := arg.([]byte); ok {
-				str = strings.Replace(str, "?", scope.AddToVars(bytes), 1)
-			} else if values := reflect.ValueOf(arg); values.Len() > 0 {
-				var tempMarks []string
-				for i := 0; i < values.Len(); i++ {
-					tempMarks = append(tempMarks, scope.AddToVars(values.Index(i).Interface()))
-				}
-				str = strings.Replace(str, "?", strings.Join(tempMarks, ","), 1)
-			} else {
-				str = strings.Replace(str, "?", scope.AddToVars(Expr("NULL")), 1)
-			}
-		default:
-			if valuer, ok := interface{}(arg).(driver.Valuer); ok {
-				arg, _ = valuer.Value()
-			}
-
-			str = strings.Replace(str, "?", scope.AddToVars(arg), 1)
-		}
-	}
-	return
-}
-
-func (scope *Scope) buildNotCondition(clause map[string]interface{}) (str string) {
-	var notEqualSQL string
-	var primaryKey = scope.PrimaryKey()
-
-	switch value := clause["query"].(type) {
-	case string:
-		if isNumberRegexp.MatchString(value) {
-			id, _ := strconv.Atoi(value)
-			return fmt.Sprintf("(%v <> %v)", scope.Quote(primaryKey), id)
-		} else if comparisonRegexp.MatchString(value) {
-			str = fmt.Sprintf(" NOT (%v) ", value)
-			notEqualSQL = fmt.Sprintf("NOT (%v)", value)
-		} else {
-			str = fmt.Sprintf("(%v.%v NOT IN (?))", scope.QuotedTableName(), scope.Quote(value))
-			notEqualSQL = fmt.Sprintf("(%v.%v <> ?)", scope.QuotedTableName(), scope.Quote(value))
-		}
-	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, sql.NullInt64:
-		return fmt.Sprintf("(%v.%v <> %v)", scope.QuotedTableName(), scope.Quote(primaryKey), value)
-	case []int, []int8, []int16, []int32, []int64, []uint, []uint8, []uint16, []uint32, []uint64, []string:
-		if reflect.ValueOf(value).Len() > 0 {
-			str = fmt.Sprintf("(%v.%v NOT IN (?))", scope.QuotedTableName(), scope.Quote(primaryKey))
-			clause["args"] = []interface{}{value}
-		} else {
-			return ""
-		}
-	case map[string]interface{}:
-		var sqls []string
-		for key, value := range value {
-			if value != nil {
-				sqls = append(sqls, fmt.Sprintf("(%v.%v <> %v)", scope.QuotedTableName(), scope.Quote(key), scope.AddToVars(value)))
-			} else {
-				sqls = append(sqls, fmt.Sprintf("(%v.%v IS NOT NULL)", scope.QuotedTableName(), scope.Quote(key)))
-			}
-		}
-		return strings.Join(sqls, " AND ")
-	case interface{}:
-		var sqls []string
-		var newScope = scope.New(value)
-		for _, field := range newScope.Fields() {
-			if !field.IsBlank {
-				sqls = append(sqls, fmt.Sprintf("(%v.%v <> %v)", scope.QuotedTableName(), scope.Quote(field.DBName), scope.AddToVars(field.Field.Interface())))
-			}
-		}
-		return strings.Join(sqls, " AND ")
-	}
-
-	args := clause["args"].([]interface{})
-	for _, arg := range args {
-		switch reflect.ValueOf(arg).Kind() {
-		case reflect.Slice: // For where("id in (?)", []int64{1,2})
-			if bytes, ok := arg.([]byte); ok {
-				str = strings.Replace(str, "?", scope.AddToVars(bytes), 1)
-			} else if values := reflect.ValueOf(arg); values.Len() > 0 {
-				var tempMarks []string
-				for i := 0; i < values.Len(); i++ {
-					tempMarks = append(tempMarks, scope.AddToVars(values.Index(i).Interface()))
-				}
-				str = strings.Replace(str, "?", strings.Join(tempMarks, ","), 1)
-			} else {
-				str = strings.Replace(str, "?", scope.AddToVars(Expr("NULL")), 1)
-			}
-		default:
-			if scanner, ok := interface{}(arg).(driver.Valuer); ok {
-				arg, _ = scanner.Value()
-			}
-			str = strings.Replace(notEqualSQL, "?", scope.AddToVars(arg), 1)
-		}
-	}
-	return
-}
-
-func (scope *Scope) buildSelectQuery(clause map[string]interface{}) (str string) {
-	switch value := clause["query"].(type) {
-	case string:
-		str = value
-	case []string:
-		str = strings.Join(value, ", ")
-	}
-
-	args := clause["args"].([]interface{})
-	for _, arg := range args {
-		switch reflect.ValueOf(arg).Kind() {
-		case reflect.Slice:
-			values := reflect.ValueOf(arg)
-			var tempMarks []string
-			for i := 0; i < values.Len(); i++ {
-				tempMarks = append(tempMarks, scope.AddToVars(values.Index(i).Interface()))
-			}
-			str = strings.Replace(str, "?", strings.Join(tempMarks, ","), 1)
-		default:
-			if valuer, ok := interface{}(arg).(driver.Valuer); ok {
-				arg, _ = valuer.Value()
-			}
-			str = strings.Replace(str, "?", scope.AddToVars(arg), 1)
-		}
-	}
-	return
-}
-
-func (scope *Scope) whereSQL() (sql string) {
-	var (
-		quotedTableName                                = scope.QuotedTableName()
-		deletedAtField, hasDeletedAtField              = scope.FieldByName("DeletedAt")
-		primaryConditions, andConditions, orConditions []string
-	)
-
-	if !scope.Search.Unscoped && hasDeletedAtField {
-		sql := fmt.Sprintf("%v.%v IS NULL", quotedTableName, scope.Quote(deletedAtField.DBName))
-		primaryConditions = append(primaryConditions, sql)
-	}
-
-	if !scope.PrimaryKeyZero() {
-		for _, field := range scope.PrimaryFields() {
-			sql := fmt.Sprintf("%v.%v = %v", quotedTableName, scope.Quote(field.DBName), scope.AddToVars(field.Field.Interface()))
-			primaryConditions = append(primaryConditions, sql)
-		}
-	}
 
-	for _, clause := range scope.Search.whereConditions {
-		if sql := scope.buildWhereCondition(clause); sql != "" {
-			andConditions = append(andConditions, sql)
-		}
+	for _, joined := range joinedStructs {
+		joined.set(values)
 	}
-
-	for _, clause := range scope.Search.orConditions {
-		if sql := scope.buildWhereCondition(clause); sql != "" {
-			orConditions = append(orConditions, sql)
-		}
-	}
-
-	for _, clause := range scope.Search.notConditions {
-		if sql := scope.buildNotCondition(clause); sql != "" {
-			andConditions = append(andConditions, sql)
-		}
-	}
-
-	orSQL := strings.Join(orConditions, " OR ")
-	combinedSQL := strings.Join(andConditions, " AND ")
-	if len(combinedSQL) > 0 {
-		if len(orSQL) > 0 {
-			combinedSQL = combinedSQL + " OR " + orSQL
-		}
-	} else {
-		combinedSQL = orSQL
-	}
-
-	if len(primaryConditions) > 0 {
-		sql = "WHERE " + strings.Join(primaryConditions, " AND ")
-		if len(combinedSQL) > 0 {
-			sql = sql + " AND (" + combinedSQL + ")"
-		}
-	} else if len(combinedSQL) > 0 {
-		sql = "WHERE " + combinedSQL
-	}
-	return
 }
 
-func (scope *Scope) selectSQL() string {
-	if len(scope.Search.selects) == 0 {
-		if len(scope.Search.joinConditions) > 0 {
-			return fmt.Sprintf("%v.*", scope.QuotedTableName())
-		}
-		return "*"
-	}
-	return scope.buildSelectQuery(scope.Search.selects)
+func (scope *Scope) whereSQL() string {
+	return scope.buildExpr(clause.Where{Exprs: scope.whereConditions()})
 }
 
 func (scope *Scope) orderSQL() string {
 	if len(scope.Search.orders) == 0 || scope.Search.ignoreOrderQuery {
 		return ""
 	}
-
-	var orders []string
-	for _, order := range scope.Search.orders {
-		if str, ok := order.(string); ok {
-			orders = append(orders, scope.quoteIfPossible(str))
-		} else if expr, ok := order.(*expr); ok {
-			exp := expr.expr
-			for _, arg := range expr.args {
-				exp = strings.Replace(exp, "?", scope.AddToVars(arg), 1)
-			}
-			orders = append(orders, exp)
-		}
-	}
-	return " ORDER BY " + strings.Join(orders, ",")
+	return " ORDER BY " + scope.buildExpr(scope.ordersExpr())
 }
 
 func (scope *Scope) limitAndOffsetSQL() string {
@@ -774,14 +622,7 @@ func (scope *Scope) havingSQL() string {
 		return ""
 	}
 
-	var andConditions []string
-	for _, clause := range scope.Search.havingConditions {
-		if sql := scope.buildWhereCondition(clause); sql != "" {
-			andConditions = append(andConditions, sql)
-		}
-	}
-
-	combinedSQL := strings.Join(andConditions, " AND ")
+	combinedSQL := scope.buildExpr(searchConditions{ands: scope.Search.havingConditions})
 	if len(combinedSQL) == 0 {
 		return ""
 	}
@@ -791,9 +632,9 @@ func (scope *Scope) havingSQL() string {
 
 func (scope *Scope) joinsSQL() string {
 	var joinConditions []string
-	for _, clause := range scope.Search.joinConditions {
-		if sql := scope.buildWhereCondition(clause); sql != "" {
-			joinConditions = append(joinConditions, strings.TrimSuffix(strings.TrimPrefix(sql, "("), ")"))
+	for _, condition := range scope.Search.joinConditions {
+		if sql := scope.buildExpr(condition); sql != "" {
+			joinConditions = append(joinConditions, sql)
 		}
 	}
 
@@ -801,12 +642,29 @@ func (scope *Scope) joinsSQL() string {
 }
 
 func (scope *Scope) prepareQuerySQL() {
+	scope.Raw(scope.querySQL())
+}
+
+// querySQL build the query's sql from its clauses without converting placeholders
+func (scope *Scope) querySQL() string {
 	if scope.Search.raw {
-		scope.Raw(scope.CombinedConditionSql())
-	} else {
-		scope.Raw(fmt.Sprintf("SELECT %v FROM %v %v", scope.selectSQL(), scope.QuotedTableName(), scope.CombinedConditionSql()))
+		// raw sql isn't built from clauses, so clauses added with `DB.Clauses` can't be applied
+		if len(scope.Search.clauses) > 0 {
+			scope.Err(ErrUnsupportedClause)
+		}
+		return scope.CombinedConditionSql()
 	}
-	return
+	return scope.buildClauses(scope.queryClauses(), queryClauseNames...)
+}
+
+// subQuerySQL return sql of the query used as a subquery of the scope, its vars are added after the scope's vars, so its placeholders are numbered after the scope's
+func (scope *Scope) subQuerySQL(db *DB) string {
+	subScope := db.NewScope(db.Value)
+	subScope.SQLVars = scope.SQLVars
+	sql := subScope.querySQL()
+	scope.SQLVars = subScope.SQLVars
+	scope.Err(db.Error)
+	return strings.TrimSpace(sql)
 }
 
 func (scope *Scope) inlineCondition(values ...interface{}) *Scope {
@@ -866,7 +724,7 @@ func (scope *Scope) updatedAttrsWithValues(value interface{}) (results map[strin
 
 	for key, value := range convertInterfaceToMap(value, true) {
 		if field, ok := scope.FieldByName(key); ok && scope.changeableField(field) {
-			if _, ok := value.(*expr); ok {
+			if _, ok := value.(*SQLExpr); ok {
 				hasUpdate = true
 				results[field.DBName] = value
 			} else {
@@ -906,8 +764,8 @@ func (scope *Scope) rows() (*sql.Rows, error) {
 }
 
 func (scope *Scope) initialize() *Scope {
-	for _, clause := range scope.Search.whereConditions {
-		scope.updatedAttrsWithValues(clause["query"])
+	for _, condition := range scope.Search.WhereConditions {
+		scope.updatedAttrsWithValues(conditionValues(condition))
 	}
 	scope.updatedAttrsWithValues(scope.Search.initAttrs)
 	scope.updatedAttrsWithValues(scope.Search.assignAttrs)
@@ -923,7 +781,7 @@ func (scope *Scope) pluck(column string, value interface{}) *Scope {
 	}
 
 	rows, err := scope.rows()
-	if scope.Err(err) == nil {
+	if scope.Err(err) == nil && rows != nil {
 		defer rows.Close()
 		for rows.Next() {
 			elem := reflect.New(dest.Type().Elem()).Interface()
@@ -943,7 +801,9 @@ func (scope *Scope) count(value interface{}) *Scope {
 		scope.Search.Select("count(*)")
 	}
 	scope.Search.ignoreOrderQuery = true
-	scope.Err(scope.row().Scan(value))
+	if row := scope.row(); row != nil {
+		scope.Err(row.Scan(value))
+	}
 	return scope
 }
 
@@ -960,7 +820,24 @@ func (scope *Scope) typeName() string {
 // trace print sql log
 func (scope *Scope) trace(t time.Time) {
 	if len(scope.SQL) > 0 {
-		scope.db.slog(scope.SQL, t, scope.SQLVars...)
+		// keep the statement that isn't executed in dry run mode for `ToSQL`
+		if scope.isDryRun() {
+			scope.db.InstantSet("gorm:dry_run_sql", scope.SQL).InstantSet("gorm:dry_run_vars", scope.SQLVars)
+		}
+		scope.db.slog(scope.SQL, t, scope.db.RowsAffected, scope.db.Error, scope.SQLVars...)
+
+		if threshold := scope.db.slowThreshold; threshold > 0 && !scope.isDryRun() {
+			if duration := NowFunc().Sub(t); duration >= threshold {
+				scope.db.reportSlowQuery(SlowQueryEvent{
+					Duration:     duration,
+					SQL:          scope.SQL,
+					Vars:         scope.SQLVars,
+					RowsAffected: scope.db.RowsAffected,
+					Caller:       fileWithLineNum(),
+					Table:        scope.TableName(),
+				})
+			}
+		}
 	}
 }
 
@@ -1052,11 +929,11 @@ func (scope *Scope) getTableOptions() string {
 	return tableOptions.(string)
 }
 
-func (scope *Scope) createJoinTable(field *StructField) {
+func (scope *Scope) createJoinTable(field *StructField, constraints []foreignKeyConstraint) {
 	if relationship := field.Relationship; relationship != nil && relationship.JoinTableHandler != nil {
 		joinTableHandler := relationship.JoinTableHandler
 		joinTable := joinTableHandler.Table(scope.db)
-		if !scope.Dialect().HasTable(joinTable) {
+		if !scope.hasTable(joinTable) {
 			toScope := &Scope{Value: reflect.New(field.Struct.Type).Interface()}
 
 			var sqlTypes, primaryKeys []string
@@ -1082,13 +959,33 @@ func (scope *Scope) createJoinTable(field *StructField) {
 				}
 			}
 
+			sqlTypes = append(sqlTypes, scope.inlineForeignKeyConstraints(joinTable, constraints)...)
+			scope.planReason("create join table `%v` for field `%v` of `%v`", joinTable, field.Name, scope.TableName())
+			scope.planTable(joinTable)
 			scope.Err(scope.NewDB().Exec(fmt.Sprintf("CREATE TABLE %v (%v, PRIMARY KEY (%v)) %s", scope.Quote(joinTable), strings.Join(sqlTypes, ","), strings.Join(primaryKeys, ","), scope.getTableOptions())).Error)
 		}
 		scope.NewDB().Table(joinTable).AutoMigrate(joinTableHandler)
 	}
 }
 
-func (scope *Scope) createTable() *Scope {
+func (scope *Scope) createTable(constraints []foreignKeyConstraint) *Scope {
+	scope.planReason("create table `%v`", scope.TableName())
+	scope.planTable(scope.TableName())
+	scope.Raw(scope.createTableSQL(scope.QuotedTableName(), constraints)).Exec()
+	if !scope.Dialect().InlineComments() {
+		scope.updateComments("", nil)
+	}
+
+	for _, field := range scope.GetModelStruct().StructFields {
+		scope.createJoinTable(field, constraints)
+	}
+
+	scope.autoIndex()
+	return scope
+}
+
+// createTableSQL return sql to create a table with the quoted name for the model, foreign key constraints on the table are defined inline if the dialect requires
+func (scope *Scope) createTableSQL(quotedTableName string, constraints []foreignKeyConstraint) string {
 	var tags []string
 	var primaryKeys []string
 	var primaryKeyInColumnType = false
@@ -1103,13 +1000,20 @@ func (scope *Scope) createTable() *Scope {
 				primaryKeyInColumnType = true
 			}
 
+			if comment, ok := field.TagSettings["COMMENT"]; ok && scope.Dialect().InlineComments() {
+				sqlTag += " COMMENT " + scope.Dialect().QuoteValue(comment)
+			}
 			tags = append(tags, scope.Quote(field.DBName)+" "+sqlTag)
 		}
 
 		if field.IsPrimaryKey {
 			primaryKeys = append(primaryKeys, scope.Quote(field.DBName))
 		}
-		scope.createJoinTable(field)
+	}
+
+	tags = append(tags, scope.inlineForeignKeyConstraints(scope.TableName(), constraints)...)
+	for _, check := range scope.checkConstraints() {
+		tags = append(tags, check.definition(scope))
 	}
 
 	var primaryKeyStr string
@@ -1117,10 +1021,11 @@ func (scope *Scope) createTable() *Scope {
 		primaryKeyStr = fmt.Sprintf(", PRIMARY KEY (%v)", strings.Join(primaryKeys, ","))
 	}
 
-	scope.Raw(fmt.Sprintf("CREATE TABLE %v (%v %v) %s", scope.QuotedTableName(), strings.Join(tags, ","), primaryKeyStr, scope.getTableOptions())).Exec()
-
-	scope.autoIndex()
-	return scope
+	tableOptions := scope.getTableOptions()
+	if comment := scope.tableComment(); comment != "" && scope.Dialect().InlineComments() {
+		tableOptions += " COMMENT=" + scope.Dialect().QuoteValue(comment)
+	}
+	return fmt.Sprintf("CREATE TABLE %v (%v %v) %s", quotedTableName, strings.Join(tags, ","), primaryKeyStr, tableOptions)
 }
 
 func (scope *Scope) dropTable() *Scope {
@@ -1136,22 +1041,14 @@ func (scope *Scope) dropColumn(column string) {
 	scope.Raw(fmt.Sprintf("ALTER TABLE %v DROP COLUMN %v", scope.QuotedTableName(), scope.Quote(column))).Exec()
 }
 
-func (scope *Scope) addIndex(unique bool, indexName string, column ...string) {
-	if scope.Dialect().HasIndex(scope.TableName(), indexName) {
-		return
-	}
-
-	var columns []string
-	for _, name := range column {
-		columns = append(columns, scope.quoteIfPossible(name))
-	}
-
-	sqlCreate := "CREATE INDEX"
-	if unique {
-		sqlCreate = "CREATE UNIQUE INDEX"
+func (scope *Scope) renameColumn(oldName string, newName string) {
+	if sql, err := scope.Dialect().RenameColumnSQL(scope.TableName(), oldName, newName); scope.Err(err) == nil {
+		scope.Raw(sql).Exec()
 	}
+}
 
-	scope.Raw(fmt.Sprintf("%s %v ON %v(%v) %v", sqlCreate, indexName, scope.QuotedTableName(), strings.Join(columns, ", "), scope.whereSQL())).Exec()
+func (scope *Scope) addIndex(unique bool, indexName string, column ...string) {
+	scope.createIndex(scope.newIndex(unique, indexName, column...))
 }
 
 func (scope *Scope) addForeignKey(field string, dest string, onDelete string, onUpdate string) {
@@ -1168,61 +1065,203 @@ func (scope *Scope) removeIndex(indexName string) {
 	scope.Dialect().RemoveIndex(scope.TableName(), indexName)
 }
 
-func (scope *Scope) autoMigrate() *Scope {
+func (scope *Scope) renameIndex(oldName string, newName string) {
+	statements, err := scope.Dialect().RenameIndexSQL(scope.TableName(), oldName, newName)
+	if scope.Err(err) != nil {
+		return
+	}
+
+	for _, sql := range statements {
+		scope.Raw(sql).Exec()
+	}
+}
+
+func (scope *Scope) autoMigrate(constraints []foreignKeyConstraint) *Scope {
 	tableName := scope.TableName()
 	quotedTableName := scope.QuotedTableName()
 
+	if scope.isPlannedTable(tableName) {
+		return scope
+	}
+
 	if !scope.Dialect().HasTable(tableName) {
-		scope.createTable()
+		scope.createTable(constraints)
 	} else {
+		columnTypes := map[string]ColumnType{}
+		if columns, err := scope.Dialect().ColumnTypes(tableName); scope.Err(err) == nil {
+			for _, column := range columns {
+				columnTypes[column.Name] = column
+			}
+		}
+
+		var (
+			columns  []string
+			recreate bool
+		)
 		for _, field := range scope.GetModelStruct().StructFields {
 			if !scope.Dialect().HasColumn(tableName, field.DBName) {
 				if field.IsNormal {
 					sqlTag := scope.Dialect().DataTypeOf(field)
+					scope.planReason("add column `%v` to `%v`", field.DBName, tableName)
 					scope.Raw(fmt.Sprintf("ALTER TABLE %v ADD %v %v;", quotedTableName, scope.Quote(field.DBName), sqlTag)).Exec()
+					columns = append(columns, field.DBName)
 				}
+			} else if columnType, ok := columnTypes[field.DBName]; ok && field.IsNormal {
+				if !scope.alterColumn(field, columnType) {
+					recreate = true
+				}
+				columns = append(columns, field.DBName)
 			}
-			scope.createJoinTable(field)
+			scope.createJoinTable(field, constraints)
+		}
+
+		if !scope.addCheckConstraints() {
+			recreate = true
+		}
+
+		if recreate {
+			scope.recreateTable(columns, constraints)
+		}
+
+		if tableComment, columnComments, err := scope.Dialect().Comments(tableName); scope.Err(err) == nil {
+			scope.updateComments(tableComment, columnComments)
 		}
 		scope.autoIndex()
 	}
 	return scope
 }
 
-func (scope *Scope) autoIndex() *Scope {
-	var indexes = map[string][]string{}
-	var uniqueIndexes = map[string][]string{}
+// columnTypeOf return the definition of the field's column in current dialect
+func (scope *Scope) columnTypeOf(field *StructField) ColumnType {
+	var (
+		sqlTag   = scope.Dialect().DataTypeOf(field)
+		lowerTag = strings.ToLower(sqlTag)
+		typ      = sqlTag
+	)
 
-	for _, field := range scope.GetStructFields() {
-		if name, ok := field.TagSettings["INDEX"]; ok {
-			names := strings.Split(name, ",")
+	// remove constraints after the type, e.g. `int AUTO_INCREMENT NOT NULL DEFAULT 1`
+	for _, keyword := range []string{" not null", " null", " unique", " default ", " primary key", " auto_increment", " autoincrement", " identity"} {
+		if idx := strings.Index(lowerTag, keyword); idx >= 0 && idx < len(typ) {
+			typ = sqlTag[:idx]
+		}
+	}
 
-			for _, name := range names {
-				if name == "INDEX" || name == "" {
-					name = fmt.Sprintf("idx_%v_%v", scope.TableName(), field.DBName)
-				}
-				indexes[name] = append(indexes[name], field.DBName)
-			}
+	columnType := ColumnType{Name: field.DBName, Type: strings.TrimSpace(typ), PrimaryKey: field.IsPrimaryKey}
+	columnType.DataType, columnType.Length = parseColumnType(columnType.Type)
+
+	// constraints could be written in tag `type` too, e.g. `gorm:"type:varchar(100) NOT NULL"`
+	notNull, defaultValue := parseColumnConstraints(sqlTag[len(typ):])
+	if _, ok := field.TagSettings["NOT NULL"]; ok {
+		notNull = true
+	}
+	columnType.Nullable = !notNull && !field.IsPrimaryKey
+	if value, ok := field.TagSettings["DEFAULT"]; ok {
+		defaultValue = &value
+	}
+	columnType.Default = defaultValue
+	return columnType
+}
+
+// allowDestructiveMigration check destructive changes are allowed by setting `gorm:allow_destructive_migration`
+func (scope *Scope) allowDestructiveMigration() bool {
+	value, ok := scope.Get("gorm:allow_destructive_migration")
+	return ok && value == true
+}
+
+// alterColumn change the column if its type, size, nullability or default differs from the field, primary keys are never changed,
+// changing the type or shrinking the size might lose data, so they are skipped unless destructive migration is allowed,
+// returns false if the dialect can't alter columns and the table needs to be recreated
+func (scope *Scope) alterColumn(field *StructField, current ColumnType) bool {
+	if field.IsPrimaryKey || current.PrimaryKey {
+		return true
+	}
+
+	// aliases of the same type written in tags or reported by the server are compared by their canonical names
+	column := scope.Dialect().NormalizeColumnType(scope.columnTypeOf(field))
+	current = scope.Dialect().NormalizeColumnType(current)
+	if current.SameType(column) && current.Nullable == column.Nullable && current.SameDefault(column) {
+		return true
+	}
+
+	sqls, err := scope.Dialect().AlterColumnSQL(scope.TableName(), current, column)
+	if err == ErrUnsupportedAlterColumn {
+		return false
+	}
+
+	destructive := current.DataType != column.DataType || (column.Length > 0 && column.Length < current.Length)
+	if destructive && !scope.allowDestructiveMigration() {
+		scope.db.warn("column `%v` of `%v` should be changed from `%v` to `%v`, skipped as it might lose data, set `gorm:allow_destructive_migration` to change it",
+			column.Name, scope.TableName(), current.Type, column.Type)
+		return true
+	}
+
+	scope.planReason("change column `%v` of `%v` from `%v` to `%v`", column.Name, scope.TableName(), current.definition(), column.definition())
+	if scope.Err(err) == nil {
+		for _, sql := range sqls {
+			scope.Raw(sql).Exec()
 		}
+	}
+	return true
+}
 
-		if name, ok := field.TagSettings["UNIQUE_INDEX"]; ok {
-			names := strings.Split(name, ",")
+// recreateTable recreate the table for the model and copy columns to it, used when the dialect can't alter columns or add constraints, e.g. sqlite,
+// columns not defined by the model are dropped, so it is skipped unless destructive migration is allowed
+func (scope *Scope) recreateTable(columns []string, constraints []foreignKeyConstraint) {
+	tableName := scope.TableName()
+	if !scope.allowDestructiveMigration() {
+		scope.db.warn("table `%v` should be recreated to change its columns or constraints, skipped as columns not defined by the model will be dropped, set `gorm:allow_destructive_migration` to recreate it", tableName)
+		return
+	}
 
-			for _, name := range names {
-				if name == "UNIQUE_INDEX" || name == "" {
-					name = fmt.Sprintf("uix_%v_%v", scope.TableName(), field.DBName)
+	var (
+		newTableName  = scope.Quote(tableName + "__new")
+		quotedColumns []string
+	)
+	for _, column := range columns {
+		quotedColumns = append(quotedColumns, scope.Quote(column))
+	}
+
+	scope.planReason("recreate table `%v` to change its columns or constraints", tableName)
+	scope.Begin()
+	scope.Raw(scope.createTableSQL(newTableName, constraints)).Exec()
+	scope.Raw(fmt.Sprintf("INSERT INTO %v (%v) SELECT %v FROM %v", newTableName, strings.Join(quotedColumns, ","), strings.Join(quotedColumns, ","), scope.QuotedTableName())).Exec()
+	scope.Raw(fmt.Sprintf("DROP TABLE %v", scope.QuotedTableName())).Exec()
+	scope.Raw(fmt.Sprintf("ALTER TABLE %v RENAME TO %v", newTableName, scope.QuotedTableName())).Exec()
+	scope.CommitOrRollback()
+}
+
+func (scope *Scope) autoIndex() *Scope {
+	var (
+		names   []string
+		indexes = map[string]*Index{}
+	)
+
+	for _, field := range scope.GetStructFields() {
+		for _, tag := range []struct {
+			name, prefix string
+			unique       bool
+		}{{"INDEX", "idx", false}, {"UNIQUE_INDEX", "uix", true}} {
+			value, ok := field.TagSettings[tag.name]
+			if !ok {
+				continue
+			}
+
+			defaultName := fmt.Sprintf("%v_%v_%v", tag.prefix, scope.TableName(), field.DBName)
+			for _, index := range scope.parseIndexTag(field, value, tag.unique, defaultName) {
+				if existing, ok := indexes[index.Name]; ok {
+					existing.merge(index)
+				} else {
+					names = append(names, index.Name)
+					indexes[index.Name] = index
 				}
-				uniqueIndexes[name] = append(uniqueIndexes[name], field.DBName)
 			}
 		}
 	}
 
-	for name, columns := range indexes {
-		scope.NewDB().Model(scope.Value).AddIndex(name, columns...)
-	}
-
-	for name, columns := range uniqueIndexes {
-		scope.NewDB().Model(scope.Value).AddUniqueIndex(name, columns...)
+	for _, name := range names {
+		newScope := scope.NewDB().Model(scope.Value).Unscoped().NewScope(scope.Value)
+		newScope.createIndex(*indexes[name])
+		scope.Err(newScope.db.Error)
 	}
 
 	return scope
@@ -1310,8 +1349,18 @@ func (scope *Scope) getColumnAsScope(column string) *Scope {
 }
 
 func (scope *Scope) hasConditions() bool {
-	return !scope.PrimaryKeyZero() ||
-		len(scope.Search.whereConditions) > 0 ||
-		len(scope.Search.orConditions) > 0 ||
-		len(scope.Search.notConditions) > 0
+	if !scope.PrimaryKeyZero() ||
+		len(scope.Search.WhereConditions) > 0 ||
+		len(scope.Search.OrConditions) > 0 ||
+		len(scope.Search.NotConditions) > 0 {
+		return true
+	}
+
+	// where clauses added with `DB.Clauses` are conditions too
+	for _, c := range scope.Search.clauses {
+		if where, ok := c.(clause.Where); ok && len(where.Exprs) > 0 {
+			return true
+		}
+	}
+	return false
 }
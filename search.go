@@ -3,15 +3,20 @@ package gorm
 import (
 	"fmt"
 	"regexp"
+	"strings"
+
+	"github.com/jinzhu/gorm/clause"
 )
 
+// search options of the statement, conditions added with `Where`, `Or` and `Not` are kept as clause expressions,
+// which callbacks registered before the default ones could inspect or rewrite through `Scope.Search`
 type search struct {
 	db               *DB
-	whereConditions  []map[string]interface{}
-	orConditions     []map[string]interface{}
-	notConditions    []map[string]interface{}
-	havingConditions []map[string]interface{}
-	joinConditions   []map[string]interface{}
+	WhereConditions  []clause.Expression
+	OrConditions     []clause.Expression
+	NotConditions    []clause.Expression
+	havingConditions []clause.Expression
+	joinConditions   []clause.Expr
 	initAttrs        []interface{}
 	assignAttrs      []interface{}
 	selects          map[string]interface{}
@@ -22,6 +27,8 @@ type search struct {
 	limit            interface{}
 	group            string
 	tableName        string
+	tableExpr        *SQLExpr
+	clauses          []clause.Interface
 	raw              bool
 	Unscoped         bool
 	ignoreOrderQuery bool
@@ -38,17 +45,17 @@ func (s *search) clone() *search {
 }
 
 func (s *search) Where(query interface{}, values ...interface{}) *search {
-	s.whereConditions = append(s.whereConditions, map[string]interface{}{"query": query, "args": values})
+	s.WhereConditions = append(s.WhereConditions, toCondition(query, values))
 	return s
 }
 
 func (s *search) Not(query interface{}, values ...interface{}) *search {
-	s.notConditions = append(s.notConditions, map[string]interface{}{"query": query, "args": values})
+	s.NotConditions = append(s.NotConditions, toNotCondition(query, values))
 	return s
 }
 
 func (s *search) Or(query interface{}, values ...interface{}) *search {
-	s.orConditions = append(s.orConditions, map[string]interface{}{"query": query, "args": values})
+	s.OrConditions = append(s.OrConditions, toCondition(query, values))
 	return s
 }
 
@@ -75,6 +82,8 @@ func (s *search) Order(value interface{}, reorder ...bool) *search {
 
 var distinctSQLRegexp = regexp.MustCompile(`(?i)distinct[^a-z]+[a-z]+`)
 
+var tableAliasRegexp = regexp.MustCompile(`(?i)\s(?:AS\s+)?(\w+)\s*$`)
+
 func (s *search) Select(query interface{}, args ...interface{}) *search {
 	if distinctSQLRegexp.MatchString(fmt.Sprint(query)) {
 		s.ignoreOrderQuery = true
@@ -84,6 +93,18 @@ func (s *search) Select(query interface{}, args ...interface{}) *search {
 	return s
 }
 
+// selectExpr return the expression of selected columns with their vars, e.g. `Select("name, ? AS role", "admin")`
+func (s *search) selectExpr() (expr clause.Expr) {
+	switch query := s.selects["query"].(type) {
+	case string:
+		expr.SQL = query
+	case []string:
+		expr.SQL = strings.Join(query, ", ")
+	}
+	expr.Vars, _ = s.selects["args"].([]interface{})
+	return
+}
+
 func (s *search) Omit(columns ...string) *search {
 	s.omits = columns
 	return s
@@ -105,12 +126,12 @@ func (s *search) Group(query string) *search {
 }
 
 func (s *search) Having(query string, values ...interface{}) *search {
-	s.havingConditions = append(s.havingConditions, map[string]interface{}{"query": query, "args": values})
+	s.havingConditions = append(s.havingConditions, namedExpr(query, values))
 	return s
 }
 
 func (s *search) Joins(query string, values ...interface{}) *search {
-	s.joinConditions = append(s.joinConditions, map[string]interface{}{"query": query, "args": values})
+	s.joinConditions = append(s.joinConditions, namedExpr(query, values))
 	return s
 }
 
@@ -136,8 +157,15 @@ func (s *search) unscoped() *search {
 	return s
 }
 
-func (s *search) Table(name string) *search {
-	s.tableName = name
+func (s *search) Table(name string, args ...interface{}) *search {
+	s.tableName, s.tableExpr = name, nil
+	if len(args) > 0 {
+		s.tableExpr = Expr(name, args...)
+		// refer to the table with its alias, e.g. `(?) AS u`
+		if matches := tableAliasRegexp.FindStringSubmatch(name); matches != nil {
+			s.tableName = matches[1]
+		}
+	}
 	return s
 }
 
@@ -12,7 +12,7 @@ func TestCloneSearch(t *testing.T) {
 	s1 := s.clone()
 	s1.Where("age = ?", 20).Order("age").Attrs("email", "a@e.org").Select("email")
 
-	if reflect.DeepEqual(s.whereConditions, s1.whereConditions) {
+	if reflect.DeepEqual(s.WhereConditions, s1.WhereConditions) {
 		t.Errorf("Where should be copied")
 	}
 
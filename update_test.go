@@ -463,3 +463,76 @@ func TestUpdateDecodeVirtualAttributes(t *testing.T) {
 		t.Errorf("should decode virtual attributes to struct, so it could be used in callbacks")
 	}
 }
+
+type VersionedProduct struct {
+	ID      uint
+	Name    string
+	Version gorm.Version
+}
+
+type RevisionedProduct struct {
+	ID       uint
+	Name     string
+	Revision int64 `gorm:"version"`
+}
+
+func TestOptimisticLock(t *testing.T) {
+	DB.DropTableIfExists(&VersionedProduct{}, &RevisionedProduct{})
+	DB.AutoMigrate(&VersionedProduct{}, &RevisionedProduct{})
+
+	product := VersionedProduct{Name: "versioned"}
+	DB.Create(&product)
+	if product.Version != 1 {
+		t.Errorf("Version should be initialized to 1 when creating, but got %v", product.Version)
+	}
+
+	var stale VersionedProduct
+	DB.First(&stale, product.ID)
+
+	product.Name = "versioned 2"
+	if err := DB.Save(&product).Error; err != nil || product.Version != 2 {
+		t.Errorf("Version should be increased when saving, but got %v, %v", product.Version, err)
+	}
+
+	stale.Name = "stale"
+	if err := DB.Save(&stale).Error; err != gorm.ErrStaleObject {
+		t.Errorf("Should get ErrStaleObject when saving stale record, but got %v", err)
+	}
+
+	if err := DB.Model(&stale).Update("name", "stale").Error; err != gorm.ErrStaleObject {
+		t.Errorf("Should get ErrStaleObject when updating stale record, but got %v", err)
+	}
+
+	var result VersionedProduct
+	if DB.First(&result, product.ID); result.Name != "versioned 2" || result.Version != 2 {
+		t.Errorf("Stale record should not be saved, but got %+v", result)
+	}
+
+	if err := DB.Model(&product).Update("name", "versioned 3").Error; err != nil || product.Version != 3 {
+		t.Errorf("Version should be increased when updating, but got %v, %v", product.Version, err)
+	}
+
+	if err := DB.Model(&product).UpdateColumn("name", "versioned 4").Error; err != nil || product.Version != 4 {
+		t.Errorf("Version should be increased when updating column, but got %v, %v", product.Version, err)
+	}
+
+	if err := DB.Set("gorm:optimistic_lock", false).Model(&stale).UpdateColumn("name", "unlocked").Error; err != nil {
+		t.Errorf("No error should happen when optimistic lock is disabled, but got %v", err)
+	}
+
+	if DB.First(&result, product.ID); result.Name != "unlocked" || result.Version != 4 {
+		t.Errorf("Version should not be changed when optimistic lock is disabled, but got %+v", result)
+	}
+
+	revisioned := RevisionedProduct{Name: "revisioned"}
+	DB.Create(&revisioned)
+	staleRevisioned := revisioned
+
+	if err := DB.Model(&revisioned).Updates(RevisionedProduct{Name: "revisioned 2"}).Error; err != nil || revisioned.Revision != 2 {
+		t.Errorf("Field tagged with version should be increased when updating, but got %v, %v", revisioned.Revision, err)
+	}
+
+	if err := DB.Model(&staleRevisioned).Updates(RevisionedProduct{Name: "stale"}).Error; err != gorm.ErrStaleObject {
+		t.Errorf("Should get ErrStaleObject when updating stale record, but got %v", err)
+	}
+}
@@ -7,9 +7,12 @@ import (
 	"reflect"
 	"regexp"
 	"runtime"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/jinzhu/gorm/clause"
 )
 
 // NowFunc returns current time, this function is exported in order to be able
@@ -113,16 +116,29 @@ func ToDBName(name string) string {
 	return s
 }
 
-// SQL expression
-type expr struct {
+// SQLExpr raw SQL expression with its vars created with `Expr`, e.g. values of updates, or the condition of `OnConflict`
+type SQLExpr struct {
 	expr string
 	args []interface{}
 }
 
 // Expr generate raw SQL expression, for example:
 //     DB.Model(&product).Update("price", gorm.Expr("price * ? + ?", 2, 100))
-func Expr(expression string, args ...interface{}) *expr {
-	return &expr{expr: expression, args: args}
+func Expr(expression string, args ...interface{}) *SQLExpr {
+	return &SQLExpr{expr: expression, args: args}
+}
+
+// Or combine conditions with OR, conditions could be strings, expressions, maps, structs, queries, other groups or clause expressions,
+// the group is a `clause.OrConditions` of converted conditions, for example:
+//     DB.Where("age > ?", 18).Where(gorm.Or(gorm.Expr("name = ?", "jinzhu"), gorm.And("role = 'admin'", map[string]interface{}{"active": true})))
+//     // WHERE (age > 18) AND ((name = 'jinzhu') OR ((role = 'admin') AND ("users"."active" = true)))
+func Or(conditions ...interface{}) clause.Expression {
+	return clause.Or(toConditions(conditions)...)
+}
+
+// And combine conditions with AND, conditions could be the same values as `Or`, the group is a `clause.AndConditions`
+func And(conditions ...interface{}) clause.Expression {
+	return clause.And(toConditions(conditions)...)
 }
 
 func indirect(reflectValue reflect.Value) reflect.Value {
@@ -280,3 +296,52 @@ func addExtraSpaceIfExist(str string) string {
 	}
 	return ""
 }
+
+// interpolateVars replace placeholders of the sql with vars quoted by the dialect, placeholders in quoted strings and identifiers are kept
+func interpolateVars(dialect Dialect, sql string, vars []interface{}) string {
+	var (
+		buf      bytes.Buffer
+		idx      int
+		quote    byte
+		// postgres numbers placeholders like `$1`, others use `?`
+		numbered = dialect.BindVar(1) != dialect.BindVar(2)
+	)
+
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '?' && !numbered && idx < len(vars):
+			buf.WriteString(dialect.QuoteValue(vars[idx]))
+			idx++
+			continue
+		case c == '$' && numbered:
+			j := i + 1
+			for j < len(sql) && sql[j] >= '0' && sql[j] <= '9' {
+				j++
+			}
+			if n, err := strconv.Atoi(sql[i+1 : j]); err == nil && n > 0 && n <= len(vars) {
+				buf.WriteString(dialect.QuoteValue(vars[n-1]))
+				i = j - 1
+				continue
+			}
+		}
+		buf.WriteByte(c)
+	}
+	return buf.String()
+}
+
+func toInt64(value reflect.Value) int64 {
+	switch value = indirect(value); value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return value.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(value.Uint())
+	}
+	return 0
+}